@@ -0,0 +1,107 @@
+package mapper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/androidsr/sc-go/syaml"
+	"gorm.io/gorm"
+)
+
+var (
+	// 命名数据源，Initdb 时按 gorm.datasources 配置注册，默认数据源名称为空
+	dataSources = make(map[string]*gorm.DB)
+	dsLock      sync.RWMutex
+)
+
+type primaryKey struct{}
+
+// 注册命名数据源
+func Register(name string, db *gorm.DB) {
+	dsLock.Lock()
+	defer dsLock.Unlock()
+	dataSources[name] = db
+}
+
+// 获取命名数据源，name 为空时返回 Initdb 创建的默认数据源
+func Use(name string) (*gorm.DB, error) {
+	dsLock.RLock()
+	defer dsLock.RUnlock()
+	db, ok := dataSources[name]
+	if !ok || db == nil {
+		return nil, fmt.Errorf("数据源不存在:%s", name)
+	}
+	return db, nil
+}
+
+// 查询也使用主库，用于写入后立即读取等场景
+func (m *Mapper[T]) ForcePrimary() *Mapper[T] {
+	return &Mapper[T]{ForcePrimary(m.DB)}
+}
+
+// 返回查询也使用主库的连接
+func ForcePrimary(db *gorm.DB) *gorm.DB {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return db.WithContext(context.WithValue(ctx, primaryKey{}, true))
+}
+
+// 多租户、字段加密为全局设置，沿用默认数据源配置，命名数据源不可单独配置
+func checkDatasource(name string, config *syaml.GormInfo) error {
+	if config == nil {
+		return fmt.Errorf("数据源配置为空:%s", name)
+	}
+	if config.Tenant != nil || config.Encrypt != nil || len(config.Datasources) > 0 {
+		return fmt.Errorf("数据源 %s 不支持单独配置多租户、字段加密及命名数据源", name)
+	}
+	return nil
+}
+
+// 读写分离连接池：查询轮询只读副本，写操作及事务使用主库
+type resolver struct {
+	primary  *sql.DB
+	replicas []*sql.DB
+	next     uint32
+}
+
+// 查询语句且未强制主库时使用只读副本
+func (m *resolver) reader(ctx context.Context, query string) *sql.DB {
+	if len(m.replicas) == 0 || ctx.Value(primaryKey{}) != nil {
+		return m.primary
+	}
+	if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(query)), "select") {
+		return m.primary
+	}
+	n := atomic.AddUint32(&m.next, 1)
+	return m.replicas[n%uint32(len(m.replicas))]
+}
+
+func (m *resolver) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return m.primary.PrepareContext(ctx, query)
+}
+
+func (m *resolver) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.primary.ExecContext(ctx, query, args...)
+}
+
+func (m *resolver) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return m.reader(ctx, query).QueryContext(ctx, query, args...)
+}
+
+func (m *resolver) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.reader(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+func (m *resolver) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return m.primary.BeginTx(ctx, opts)
+}
+
+func (m *resolver) GetDBConn() (*sql.DB, error) {
+	return m.primary, nil
+}
@@ -0,0 +1,189 @@
+package mapper
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/androidsr/sc-go/sbuilder"
+	"github.com/androidsr/sc-go/syaml"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 按配置开启字段加密：写入前加密 encrypt tag 标记的字段，写入及查询后解密；
+// 实体条件及 map 条件中的加密列按确定性加密转换，字符串条件（Where("phone = ?")）不处理
+func registerEncrypt(db *gorm.DB, config *syaml.EncryptInfo) error {
+	if config == nil {
+		return nil
+	}
+	keys := make(map[string][]byte, len(config.Keys))
+	for id, v := range config.Keys {
+		key, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return fmt.Errorf("密钥格式错误 %s: %w", id, err)
+		}
+		keys[id] = key
+	}
+	if err := sbuilder.SetEncryptKeys(config.Key, keys); err != nil {
+		return err
+	}
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("sc:encrypt", encryptDest); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("sc:decrypt", decryptDest); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("sc:encrypt", func(db *gorm.DB) {
+		encryptWhere(db)
+		encryptDest(db)
+	}); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("sc:decrypt", decryptDest); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("sc:encrypt", encryptWhere); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("sc:encrypt", encryptWhere); err != nil {
+		return err
+	}
+	return cb.Query().After("gorm:query").Register("sc:decrypt", decryptDest)
+}
+
+func encryptDest(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Dest == nil {
+		return
+	}
+	if values, ok := db.Statement.Dest.(map[string]interface{}); ok {
+		encryptMap(db, values)
+		return
+	}
+	if err := sbuilder.EncryptFields(db.Statement.Dest); err != nil {
+		db.AddError(err)
+	}
+}
+
+// 按列写入（Update("phone", v)、Updates(map)、Create(map)）时加密 map 中的加密列，不修改调用方的 map
+func encryptMap(db *gorm.DB, values map[string]interface{}) {
+	if db.Statement.Schema == nil {
+		return
+	}
+	model := reflect.New(db.Statement.Schema.ModelType).Interface()
+	result := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if field := db.Statement.Schema.LookUpField(k); field != nil {
+			value, _, err := sbuilder.EncryptValue(model, field.Name, v)
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			v = value
+		}
+		result[k] = v
+	}
+	db.Statement.Dest = result
+}
+
+func decryptDest(db *gorm.DB) {
+	if db.Statement.Dest == nil {
+		return
+	}
+	if err := sbuilder.DecryptFields(db.Statement.Dest); err != nil {
+		db.AddError(err)
+	}
+}
+
+// 加密条件中加密列的值
+func encryptWhere(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	c, ok := db.Statement.Clauses["WHERE"]
+	if !ok {
+		return
+	}
+	where, ok := c.Expression.(clause.Where)
+	if !ok {
+		return
+	}
+	model := reflect.New(db.Statement.Schema.ModelType).Interface()
+	exprs, err := encryptExprs(db, model, where.Exprs)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	c.Expression = clause.Where{Exprs: exprs}
+	db.Statement.Clauses["WHERE"] = c
+}
+
+func encryptExprs(db *gorm.DB, model interface{}, exprs []clause.Expression) ([]clause.Expression, error) {
+	result := make([]clause.Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		var err error
+		switch e := expr.(type) {
+		case clause.Eq:
+			var value interface{}
+			value, _, err = encryptColumn(db, model, e.Column, e.Value)
+			if values, ok := value.([]interface{}); ok {
+				expr = clause.IN{Column: e.Column, Values: values}
+			} else {
+				expr = clause.Eq{Column: e.Column, Value: value}
+			}
+		case clause.IN:
+			values := make([]interface{}, 0, len(e.Values))
+			for _, v := range e.Values {
+				value, _, encErr := encryptColumn(db, model, e.Column, v)
+				if encErr != nil {
+					err = encErr
+					break
+				}
+				if vs, ok := value.([]interface{}); ok {
+					values = append(values, vs...)
+				} else {
+					values = append(values, value)
+				}
+			}
+			expr = clause.IN{Column: e.Column, Values: values}
+		case clause.Like:
+			// 密文无法模糊匹配
+			if _, encrypted, _ := encryptColumn(db, model, e.Column, e.Value); encrypted {
+				err = errors.New("加密字段只支持等值查询: like")
+			}
+		case clause.AndConditions:
+			e.Exprs, err = encryptExprs(db, model, e.Exprs)
+			expr = e
+		case clause.OrConditions:
+			e.Exprs, err = encryptExprs(db, model, e.Exprs)
+			expr = e
+		case clause.NotConditions:
+			e.Exprs, err = encryptExprs(db, model, e.Exprs)
+			expr = e
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, expr)
+	}
+	return result, nil
+}
+
+// 加密列的条件值，按实体字段名匹配列；不是加密列时返回 false
+func encryptColumn(db *gorm.DB, model interface{}, column interface{}, value interface{}) (interface{}, bool, error) {
+	var name string
+	switch c := column.(type) {
+	case clause.Column:
+		name = c.Name
+	case string:
+		name = c
+	}
+	field := db.Statement.Schema.LookUpField(name)
+	if field == nil {
+		return value, false, nil
+	}
+	return sbuilder.EncryptQuery(model, field.Name, value)
+}
@@ -1,10 +1,14 @@
 package mapper
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/androidsr/sc-go/model"
+	"github.com/androidsr/sc-go/sbuilder"
+	"github.com/androidsr/sc-go/smigrate"
 	"github.com/androidsr/sc-go/syaml"
 
 	"gorm.io/driver/mysql"
@@ -25,27 +29,46 @@ type Mapper[T any] struct {
 
 // New 初始化数据库连接
 func Initdb(config *syaml.GormInfo) *gorm.DB {
-	var dialector gorm.Dialector
-	// 根据配置选择对应的数据库驱动
-	switch config.Driver {
-	case "mysql":
-		dialector = mysql.Open(config.Url)
-	case "postgres":
-		dialector = postgres.Open(config.Url)
-	case "sqlite":
-		dialector = sqlite.Open(config.Url)
+	for name, v := range config.Datasources {
+		if err := checkDatasource(name, v); err != nil {
+			log.Printf("命名数据源初始化失败:%s", err.Error())
+			return nil
+		}
+	}
+	primary := newGorm(config, config)
+	if primary == nil {
+		return nil
 	}
+	sources := make(map[string]*gorm.DB, len(config.Datasources))
+	for name, v := range config.Datasources {
+		ds := newGorm(v, config)
+		if ds == nil {
+			log.Printf("命名数据源初始化失败:%s", name)
+			return nil
+		}
+		sources[name] = ds
+	}
+	db = primary
+	Register("", primary)
+	for name, ds := range sources {
+		Register(name, ds)
+	}
+	return db
+}
+
+// 创建数据源：主库及只读副本，多租户、字段加密按默认数据源配置 global 注册
+func newGorm(config *syaml.GormInfo, global *syaml.GormInfo) *gorm.DB {
 	// 配置日志
 	var showLog logger.Interface
 	if config.ShowSql {
 		showLog = logger.Default.LogMode(logger.Info)
 	}
 	// 初始化数据库连接
-	var err error
-	db, err = gorm.Open(dialector, &gorm.Config{
+	db, err := gorm.Open(dialector(config.Driver, config.Url), &gorm.Config{
 		SkipDefaultTransaction: true,
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true,
+			TablePrefix:   config.TablePrefix,
 		},
 		Logger: showLog,
 	})
@@ -61,9 +84,61 @@ func Initdb(config *syaml.GormInfo) *gorm.DB {
 	}
 	sqlDB.SetMaxIdleConns(config.MaxIdle)
 	sqlDB.SetMaxOpenConns(config.MaxOpen)
+	if len(config.Replicas) > 0 {
+		pool := &resolver{primary: sqlDB}
+		for _, url := range config.Replicas {
+			replica, err := openReplica(config, url)
+			if err != nil {
+				log.Printf("只读副本不可用，已忽略:%s", err.Error())
+				continue
+			}
+			pool.replicas = append(pool.replicas, replica)
+		}
+		db.ConnPool = pool
+		db.Statement.ConnPool = pool
+	}
+	if err := registerTenant(db, global.Tenant); err != nil {
+		log.Printf("多租户初始化失败:%s", err.Error())
+		return nil
+	}
+	if err := registerEncrypt(db, global.Encrypt); err != nil {
+		log.Printf("字段加密初始化失败:%s", err.Error())
+		return nil
+	}
+	if err := smigrate.Auto(sqlDB, config.Driver, config.Migrate); err != nil {
+		return nil
+	}
 	return db
 }
 
+// 根据配置选择对应的数据库驱动
+func dialector(driver, url string) gorm.Dialector {
+	switch driver {
+	case "mysql":
+		return mysql.Open(url)
+	case "postgres":
+		return postgres.Open(url)
+	case "sqlite":
+		return sqlite.Open(url)
+	}
+	return nil
+}
+
+// 打开只读副本连接并按配置设置连接池
+func openReplica(config *syaml.GormInfo, url string) (*sql.DB, error) {
+	replica, err := gorm.Open(dialector(config.Driver, url), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := replica.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxIdleConns(config.MaxIdle)
+	sqlDB.SetMaxOpenConns(config.MaxOpen)
+	return sqlDB, nil
+}
+
 func NewHelper[T any]() *Mapper[T] {
 	return &Mapper[T]{db}
 }
@@ -72,6 +147,16 @@ func NewTransaction[T any](db *gorm.DB) *Mapper[T] {
 	return &Mapper[T]{db}
 }
 
+// 按 sbuilder 注册的分表规则切换到实际表，obj 为用于计算分表的实体
+func (m *Mapper[T]) Shard(obj *T) *Mapper[T] {
+	stmt := &gorm.Statement{DB: m.DB}
+	if err := stmt.Parse(obj); err != nil {
+		log.Printf("解析实体失败: %v", err)
+		return m
+	}
+	return &Mapper[T]{m.DB.Table(sbuilder.ResolveTable(stmt.Schema.Table, obj))}
+}
+
 // Exists 判断记录是否存在
 func (m *Mapper[T]) Exists(query *T) bool {
 	return m.GetCount(query) > 0
@@ -193,7 +278,10 @@ func (m *Mapper[T]) SelectOne(data *T) error {
 
 // SelectSQL 执行SQL查询
 func (m *Mapper[T]) SelectSQL(data interface{}, sql string, values ...interface{}) error {
-	return m.DB.Raw(sql, values...).Scan(data).Error
+	if err := m.DB.Raw(sql, values...).Scan(data).Error; err != nil {
+		return err
+	}
+	return sbuilder.DecryptFields(data)
 }
 
 // SelectPage 分页查询
@@ -212,7 +300,9 @@ func (m *Mapper[T]) SelectPage(data interface{}, page *model.PageInfo, sql strin
 		}
 		result.Total = int64(count)
 		offset := (page.Current - 1) * page.Size
-		sql = fmt.Sprintf("select * from (%s) t LIMIT ? OFFSET ?", sql)
+		// 排序列只允许实体及结果结构体的列
+		orderBy := sbuilder.BuildOrderBy(sbuilder.GetDialect(m.DB.Dialector.Name()), page.Orders, m.orderColumns(data))
+		sql = fmt.Sprintf("select * from (%s) t %s LIMIT ? OFFSET ?", sql, orderBy)
 		values = append(values, page.Size, offset)
 	}
 	// 执行分页查询
@@ -220,6 +310,30 @@ func (m *Mapper[T]) SelectPage(data interface{}, page *model.PageInfo, sql strin
 		log.Printf("SelectPage Error: %v", err)
 		return nil
 	}
+	if err := sbuilder.DecryptFields(data); err != nil {
+		log.Printf("SelectPage Error: %v", err)
+		return nil
+	}
 	result.Rows = data
 	return result
 }
+
+// 排序列白名单：实体及结果结构体的json名、字段名、列名
+func (m *Mapper[T]) orderColumns(data interface{}) sbuilder.OrderColumns {
+	allowed := sbuilder.OrderColumnsOf(data)
+	stmt := &gorm.Statement{DB: m.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return allowed
+	}
+	for _, f := range stmt.Schema.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		allowed[f.DBName] = f.DBName
+		allowed[f.Name] = f.DBName
+		if json := strings.Split(f.Tag.Get("json"), ",")[0]; json != "" && json != "-" {
+			allowed[json] = f.DBName
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,103 @@
+package mapper
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/androidsr/sc-go/sbuilder"
+	"github.com/androidsr/sc-go/sgin"
+	"github.com/androidsr/sc-go/syaml"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 跳过多租户处理的标记
+const ignoreTenantKey = "sc:ignore_tenant"
+
+// 按配置开启多租户：查询、更新、删除追加当前租户条件，插入时填充租户列（Raw SQL 不处理）
+func registerTenant(db *gorm.DB, config *syaml.TenantInfo) error {
+	if config == nil {
+		return nil
+	}
+	column, key := config.Column, config.Key
+	if column == "" {
+		column = "tenant_id"
+	}
+	if key == "" {
+		key = "tenantId"
+	}
+	sbuilder.SetTenant(column, func() interface{} {
+		return sgin.GetValue(key)
+	}, config.Ignore...)
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("sc:tenant", tenantWhere); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("sc:tenant", tenantWhere); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("sc:tenant", tenantWhere); err != nil {
+		return err
+	}
+	return cb.Create().Before("gorm:create").Register("sc:tenant", tenantCreate)
+}
+
+// 返回不处理多租户的对象，用于管理员、定时任务等跨租户操作
+func (m *Mapper[T]) IgnoreTenant() *Mapper[T] {
+	return &Mapper[T]{m.DB.Set(ignoreTenantKey, true)}
+}
+
+// 当前语句的租户列及租户ID，不处理时列为空
+func tenant(db *gorm.DB) (string, interface{}) {
+	if db.Statement.Schema == nil || db.Statement.SQL.Len() > 0 {
+		return "", nil
+	}
+	if v, ok := db.Get(ignoreTenantKey); ok && v == true {
+		return "", nil
+	}
+	return sbuilder.Tenant(db.Statement.Table)
+}
+
+func tenantWhere(db *gorm.DB) {
+	column, value := tenant(db)
+	if column == "" {
+		return
+	}
+	if value == nil {
+		db.AddError(errors.New("租户信息为空"))
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: value},
+	}})
+}
+
+func tenantCreate(db *gorm.DB) {
+	column, value := tenant(db)
+	if column == "" {
+		return
+	}
+	if value == nil {
+		db.AddError(errors.New("租户信息为空"))
+		return
+	}
+	field := db.Statement.Schema.LookUpField(column)
+	if field == nil {
+		return
+	}
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := field.Set(db.Statement.Context, reflect.Indirect(rv.Index(i)), value); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(db.Statement.Context, rv, value); err != nil {
+			db.AddError(err)
+		}
+	}
+}
@@ -11,6 +11,17 @@ type PageInfo struct {
 	Current int64       `json:"current" keyword:"eq"`
 	Size    int64       `json:"size"`
 	Orders  []OrderItem `json:"orders"`
+	//偏移分页时不查询总条数
+	SkipCount bool `json:"skipCount"`
+	//游标分页：按排序列定位下一页，不查询总条数；首页 Cursor 为空
+	CursorMode bool `json:"cursorMode"`
+	//上一页返回的 nextCursor
+	Cursor string `json:"cursor"`
+}
+
+// 是否为游标分页
+func (m *PageInfo) IsCursor() bool {
+	return m.CursorMode || m.Cursor != ""
 }
 
 func (m *PageInfo) SetPage(current int64, size int64) *PageInfo {
@@ -59,6 +70,8 @@ type PageResult struct {
 	Total int64 `json:"total"`
 	//数据
 	Rows interface{} `json:"rows"`
+	//游标分页时下一页游标，没有下一页时为空
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type HttpResult struct {
@@ -0,0 +1,239 @@
+package sbuilder
+
+import (
+	"strings"
+
+	"github.com/androidsr/sc-go/sc"
+)
+
+const (
+	isNull    = "isNull"
+	isNotNull = "isNotNull"
+)
+
+// 查询条件：单列条件或 and/or 条件组，可任意嵌套
+type Condition interface {
+	// 按方言生成条件SQL（不带前导 and/or）及参数，条件为空时返回空字符串
+	Build(d Dialect) (string, []interface{})
+}
+
+// 可作为子查询的构建器：QueryBuilder、SelectBuilder
+type SqlBuilder interface {
+	Build() (string, []interface{})
+}
+
+// 条件列，通过列方法创建单列条件；值为nil或空字符串时条件为空，生成SQL时忽略
+type Column string
+
+// 创建条件列
+func Col(name string) Column {
+	return Column(name)
+}
+
+func (m Column) Eq(value interface{}) Condition         { return &cond{string(m), Eq, value} }
+func (m Column) Ne(value interface{}) Condition         { return &cond{string(m), Ne, value} }
+func (m Column) In(value interface{}) Condition         { return &cond{string(m), In, value} }
+func (m Column) NotIn(value interface{}) Condition      { return &cond{string(m), NotIn, value} }
+func (m Column) Gt(value interface{}) Condition         { return &cond{string(m), Gt, value} }
+func (m Column) Lt(value interface{}) Condition         { return &cond{string(m), Lt, value} }
+func (m Column) Ge(value interface{}) Condition         { return &cond{string(m), Ge, value} }
+func (m Column) Le(value interface{}) Condition         { return &cond{string(m), Le, value} }
+func (m Column) Between(value BetweenInfo) Condition    { return &cond{string(m), Between, value} }
+func (m Column) NotBetween(value BetweenInfo) Condition { return &cond{string(m), NotBetween, value} }
+func (m Column) Like(value interface{}) Condition       { return &cond{string(m), Like, value} }
+func (m Column) NotLike(value interface{}) Condition    { return &cond{string(m), NotLike, value} }
+func (m Column) LikeLeft(value interface{}) Condition   { return &cond{string(m), LikeLeft, value} }
+func (m Column) LikeRight(value interface{}) Condition  { return &cond{string(m), LikeRight, value} }
+
+// 为空：null 或空字符串
+func (m Column) IsNull() Condition { return &cond{string(m), isNull, nil} }
+
+// 不为空：非 null 且非空字符串
+func (m Column) IsNotNull() Condition { return &cond{string(m), isNotNull, nil} }
+
+// 单列条件
+type cond struct {
+	column  string
+	keyword string
+	value   interface{}
+}
+
+func (m *cond) Build(d Dialect) (string, []interface{}) {
+	if d == nil {
+		d = DefaultDialect()
+	}
+	switch m.keyword {
+	case isNull:
+		return "(" + m.column + " is null or " + m.column + " = '')", nil
+	case isNotNull:
+		return "(" + m.column + " is not null and " + m.column + " != '')", nil
+	case In, NotIn:
+		if m.value == nil {
+			return "", nil
+		}
+		op := " in("
+		if m.keyword == NotIn {
+			op = " not in("
+		}
+		// 子查询
+		if sub, ok := m.value.(SqlBuilder); ok {
+			sql, values := sub.Build()
+			return m.column + op + sql + ")", values
+		}
+		v := sc.AssertSliceType(m.value)
+		if len(v) == 0 {
+			return "", nil
+		}
+		return m.column + op + Placeholders(len(v)) + ")", v
+	case Between, NotBetween:
+		v, ok := m.value.(BetweenInfo)
+		if !ok || v.Left == nil || v.Left == "" || v.Right == nil || v.Right == "" {
+			return "", nil
+		}
+		op := " between ? and ?"
+		if m.keyword == NotBetween {
+			op = " not between ? and ?"
+		}
+		return m.column + op, []interface{}{v.Left, v.Right}
+	}
+	if m.value == nil || m.value == "" {
+		return "", nil
+	}
+	var op string
+	switch m.keyword {
+	case Eq:
+		op = " = ?"
+	case Ne:
+		op = " <> ?"
+	case Gt:
+		op = " > ?"
+	case Lt:
+		op = " < ?"
+	case Ge:
+		op = " >= ?"
+	case Le:
+		op = " <= ?"
+	case Like:
+		op = " like " + d.Like(true, true)
+	case NotLike:
+		op = " not like " + d.Like(true, true)
+	case LikeLeft:
+		op = " not like " + d.Like(true, false)
+	case LikeRight:
+		op = " not like " + d.Like(false, true)
+	default:
+		return "", nil
+	}
+	return m.column + op, []interface{}{m.value}
+}
+
+// 条件组：子条件用 and/or 连接，忽略空条件
+type group struct {
+	link  string
+	conds []Condition
+}
+
+// 所有条件同时满足
+func And(conds ...Condition) Condition {
+	return &group{"and", conds}
+}
+
+// 任一条件满足
+func Or(conds ...Condition) Condition {
+	return &group{"or", conds}
+}
+
+func (m *group) Build(d Dialect) (string, []interface{}) {
+	parts, values := m.parts(d)
+	switch len(parts) {
+	case 0:
+		return "", nil
+	case 1:
+		return parts[0], values
+	}
+	return "(" + strings.Join(parts, " "+m.link+" ") + ")", values
+}
+
+// 生成不带外层括号的条件，用于 where、having 子句
+func (m *group) clause(d Dialect) (string, []interface{}) {
+	parts, values := m.parts(d)
+	return strings.Join(parts, " "+m.link+" "), values
+}
+
+// 非空子条件SQL及参数
+func (m *group) parts(d Dialect) ([]string, []interface{}) {
+	parts := make([]string, 0, len(m.conds))
+	values := make([]interface{}, 0)
+	for _, c := range m.conds {
+		if c == nil {
+			continue
+		}
+		sql, vs := c.Build(d)
+		if sql == "" {
+			continue
+		}
+		parts = append(parts, sql)
+		values = append(values, vs...)
+	}
+	return parts, values
+}
+
+// 条件取反
+func Not(c Condition) Condition {
+	return &not{c}
+}
+
+type not struct {
+	cond Condition
+}
+
+func (m *not) Build(d Dialect) (string, []interface{}) {
+	if m.cond == nil {
+		return "", nil
+	}
+	sql, values := m.cond.Build(d)
+	if sql == "" {
+		return "", nil
+	}
+	return "not (" + sql + ")", values
+}
+
+// 子查询存在
+func Exists(sub SqlBuilder) Condition {
+	return &exists{"exists", sub}
+}
+
+// 子查询不存在
+func NotExists(sub SqlBuilder) Condition {
+	return &exists{"not exists", sub}
+}
+
+type exists struct {
+	op  string
+	sub SqlBuilder
+}
+
+func (m *exists) Build(d Dialect) (string, []interface{}) {
+	if m.sub == nil {
+		return "", nil
+	}
+	sql, values := m.sub.Build()
+	return m.op + " (" + sql + ")", values
+}
+
+// 自定义SQL条件，使用 ? 占位符
+func Expr(sql string, values ...interface{}) Condition {
+	return &expr{sql, values}
+}
+
+type expr struct {
+	sql    string
+	values []interface{}
+}
+
+func (m *expr) Build(d Dialect) (string, []interface{}) {
+	if strings.TrimSpace(m.sql) == "" {
+		return "", nil
+	}
+	return "(" + m.sql + ")", m.values
+}
@@ -0,0 +1,33 @@
+package sbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhere(t *testing.T) {
+	b := Builder("select * from t where 1=1 ").WithDialect(GetDialect(MySQL))
+	b.Eq("state", "1")
+	b.Where(Or(Col("a").Eq(1), Col("b").Eq(""), And(Col("c").Like("x"), Not(Col("d").In([]int{2, 3})))), Expr("e > ?", 4))
+	sql, values := b.Build()
+	want := "select * from t where 1=1  and state = ?  and ((a = ? or (c like CONCAT('%', ?, '%') and not (d in(?, ?)))) and (e > ?)) "
+	if sql != want {
+		t.Errorf("sql\n%s\n%s", sql, want)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"1", 1, "x", 2, 3, 4}) {
+		t.Errorf("values %v", values)
+	}
+}
+
+func TestMultiple(t *testing.T) {
+	b := Builder("select * from t where 1=1 ")
+	b.Multiple().Ors(b.Eq("a", 1), b.Between("b", BetweenInfo{Left: 1, Right: 2}), b.Eq("c", ""))
+	sql, values := b.Build()
+	want := "select * from t where 1=1  or (a = ? and b between ? and ?) "
+	if sql != want {
+		t.Errorf("sql\n%s\n%s", sql, want)
+	}
+	if len(values) != 3 {
+		t.Errorf("values %v", values)
+	}
+}
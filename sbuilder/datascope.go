@@ -0,0 +1,174 @@
+package sbuilder
+
+import (
+	"reflect"
+	"sync"
+)
+
+// 数据范围
+const (
+	// 全部数据
+	ScopeAll = "all"
+	// 本人数据
+	ScopeSelf = "self"
+	// 本部门数据
+	ScopeDept = "dept"
+	// 本部门及下级部门数据
+	ScopeDeptAndChild = "deptAndChild"
+	// 自定义数据范围，由实体实现 DataScoper
+	ScopeCustom = "custom"
+)
+
+var (
+	// 当前登录用户，没有时返回nil
+	scopeProvider func() *ScopeUser
+	scopeLock     sync.RWMutex
+)
+
+// 数据权限用户：当前登录用户ID、部门及数据范围
+type ScopeUser struct {
+	UserId interface{}
+	DeptId interface{}
+	// 本部门及下级部门ID，为空时只取本部门
+	DeptIds []interface{}
+	// 数据范围，为空时按本人数据处理
+	Scope string
+}
+
+// 自定义数据权限：实体或查询DTO实现，数据范围为 custom 时使用返回的条件
+type DataScoper interface {
+	DataScope(user *ScopeUser) Condition
+}
+
+// 开启数据权限：按当前登录用户的数据范围追加查询条件；provider 为nil时关闭
+func SetDataScope(provider func() *ScopeUser) {
+	scopeLock.Lock()
+	defer scopeLock.Unlock()
+	scopeProvider = provider
+}
+
+// 当前登录用户，未开启数据权限或没有登录用户时返回nil
+func CurrentScopeUser() *ScopeUser {
+	scopeLock.RLock()
+	provider := scopeProvider
+	scopeLock.RUnlock()
+	if provider == nil {
+		return nil
+	}
+	return provider()
+}
+
+// 数据权限规则：实体 scope:"user"、scope:"dept" 标记的列及自定义条件
+type ScopeRule struct {
+	UserColumn string
+	DeptColumn string
+	Custom     DataScoper
+	// 列前缀：表别名
+	Alias string
+}
+
+// 按当前登录用户生成条件；未开启数据权限时不限制，没有登录用户或缺少对应列时条件恒为假
+func (m *ScopeRule) Build(d Dialect) (string, []interface{}) {
+	scopeLock.RLock()
+	enable := scopeProvider != nil
+	scopeLock.RUnlock()
+	if !enable {
+		return "", nil
+	}
+	if d == nil {
+		d = DefaultDialect()
+	}
+	user := CurrentScopeUser()
+	if user == nil {
+		return "1 = 0", nil
+	}
+	var c Condition
+	switch user.Scope {
+	case ScopeAll:
+		return "", nil
+	case ScopeSelf, "":
+		c = m.cond(d, m.UserColumn, user.UserId, false)
+	case ScopeDept:
+		c = m.cond(d, m.DeptColumn, user.DeptId, false)
+	case ScopeDeptAndChild:
+		if len(user.DeptIds) == 0 {
+			c = m.cond(d, m.DeptColumn, []interface{}{user.DeptId}, true)
+		} else {
+			c = m.cond(d, m.DeptColumn, user.DeptIds, true)
+		}
+	case ScopeCustom:
+		if m.Custom != nil {
+			c = m.Custom.DataScope(user)
+		}
+	}
+	if c == nil {
+		return "1 = 0", nil
+	}
+	sql, values := c.Build(d)
+	if sql == "" {
+		return "1 = 0", nil
+	}
+	return sql, values
+}
+
+func (m *ScopeRule) cond(d Dialect, column string, value interface{}, in bool) Condition {
+	if column == "" || value == nil || value == "" {
+		return nil
+	}
+	column = d.Quote(column)
+	if m.Alias != "" {
+		column = m.Alias + "." + column
+	}
+	if in {
+		return Col(column).In(value)
+	}
+	return Col(column).Eq(value)
+}
+
+// 对象（或结构体切片）的数据权限条件，alias 为表别名，不为空时使用 db 列名；未声明数据权限时返回nil
+func DataScope(obj interface{}, alias string) Condition {
+	if obj == nil {
+		return nil
+	}
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	v := reflect.New(t).Elem()
+	rule := scopeRule(v, getStructMeta(v.Type()), alias != "")
+	if rule == nil {
+		return nil
+	}
+	rule.Alias = alias
+	return rule
+}
+
+// 未标记数据权限列且未实现 DataScoper 时返回nil；byDB 为true时使用 db 列名
+func scopeRule(v reflect.Value, meta *structMeta, byDB bool) *ScopeRule {
+	rule := &ScopeRule{}
+	if v.CanAddr() {
+		rule.Custom, _ = v.Addr().Interface().(DataScoper)
+	}
+	if rule.Custom == nil && v.CanInterface() {
+		rule.Custom, _ = v.Interface().(DataScoper)
+	}
+	if meta.scopeUser == nil && meta.scopeDept == nil && rule.Custom == nil {
+		return nil
+	}
+	if meta.scopeUser != nil {
+		rule.UserColumn = meta.scopeUser.TagColumn
+		if byDB {
+			rule.UserColumn = meta.scopeUser.TagDB
+		}
+	}
+	if meta.scopeDept != nil {
+		rule.DeptColumn = meta.scopeDept.TagColumn
+		if byDB {
+			rule.DeptColumn = meta.scopeDept.TagDB
+		}
+	}
+	return rule
+}
@@ -0,0 +1,37 @@
+package sbuilder
+
+import (
+	"strings"
+	"testing"
+)
+
+type scopeDemo struct {
+	Id     string `db:"id,primary_key"`
+	UserId string `db:"user_id" scope:"user"`
+	DeptId string `db:"dept_id" column:"o.dept_id" scope:"dept"`
+}
+
+func TestDataScope(t *testing.T) {
+	user := &ScopeUser{UserId: "u1", DeptId: "d1", DeptIds: []interface{}{"d1", "d2"}}
+	SetDataScope(func() *ScopeUser { return user })
+	defer SetDataScope(nil)
+	d := GetDialect("mysql")
+	sql, values := DataScope(&[]scopeDemo{}, "t").Build(d)
+	if sql != "t.`user_id` = ?" || values[0] != "u1" {
+		t.Fatal(sql, values)
+	}
+	user.Scope = ScopeDeptAndChild
+	info := GetField(&scopeDemo{}, 0)
+	b := BuildQueryDialect(info, d)
+	if strings.TrimSpace(b.Sql.String()) != "and `o`.`dept_id` in(?, ?)" || len(b.Values) != 2 {
+		t.Fatal(b.Sql.String(), b.Values)
+	}
+	user.Scope = ScopeCustom
+	if sql, _ = info.Scope.Build(d); sql != "1 = 0" {
+		t.Fatal(sql)
+	}
+	user = nil
+	if sql, _ = info.Scope.Build(d); sql != "1 = 0" {
+		t.Fatal(sql)
+	}
+}
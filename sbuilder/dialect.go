@@ -0,0 +1,521 @@
+package sbuilder
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	MySQL     = "mysql"
+	Postgres  = "postgres"
+	SQLite    = "sqlite"
+	Oracle    = "oracle"
+	SQLServer = "sqlserver"
+)
+
+var (
+	dialectLock    sync.RWMutex
+	defaultDialect Dialect = mysqlDialect{}
+	dialects               = map[string]Dialect{
+		"mysql":     mysqlDialect{},
+		"postgres":  postgresDialect{},
+		"pgx":       postgresDialect{},
+		"sqlite":    sqliteDialect{},
+		"sqlite3":   sqliteDialect{},
+		"oracle":    oracleDialect{},
+		"godror":    oracleDialect{},
+		"oci8":      oracleDialect{},
+		"sqlserver": sqlserverDialect{},
+		"mssql":     sqlserverDialect{},
+	}
+)
+
+// 数据库方言：屏蔽不同数据库在占位符、模糊匹配、分页及标识符引用上的差异。
+// 构建器内部统一使用 ? 占位符，执行前再通过 Rebind 转换。
+type Dialect interface {
+	// 方言名称
+	Name() string
+	// 将 ? 占位符转换为数据库对应格式（$1、:1、@p1）
+	Rebind(sql string) string
+	// 引用标识符，支持 t.column 形式，表达式原样返回
+	Quote(name string) string
+	// 模糊匹配右侧表达式，left/right 表示是否在左右两侧拼接 %
+	Like(left, right bool) string
+	// 分页语句，orderBy 为完整的 order by 子句（可为空）
+	Page(sql string, orderBy string, size, offset int64) (string, []interface{})
+	// 多行插入语句，参数按行依次排列
+	Insert(table string, columns []string, rows int) string
+	// 插入或更新语句，conflict 为冲突判断列，update 为冲突时更新的列
+	Upsert(table string, columns []string, rows int, conflict []string, update []string) string
+	// 保存点语句，release 为空表示数据库不支持释放保存点
+	Savepoint(name string) (save, rollback, release string)
+}
+
+// 批量语句限制，自定义方言可选实现
+type BatchLimiter interface {
+	// 单条语句允许的最大参数数及多行插入的最大行数，0 为不限制
+	BatchLimit() (params int, rows int)
+}
+
+// 方言的批量语句限制：最大参数数及最大行数，0 为不限制
+func BatchLimit(d Dialect) (params int, rows int) {
+	if v, ok := d.(BatchLimiter); ok {
+		return v.BatchLimit()
+	}
+	return 0, 0
+}
+
+// 注册方言（按驱动名称）
+func RegisterDialect(driver string, d Dialect) {
+	dialectLock.Lock()
+	defer dialectLock.Unlock()
+	dialects[driver] = d
+}
+
+// 按驱动名称获取方言，未知驱动按 MySQL 处理
+func GetDialect(driver string) Dialect {
+	dialectLock.RLock()
+	defer dialectLock.RUnlock()
+	d, ok := dialects[strings.ToLower(driver)]
+	if !ok {
+		log.Printf("未知的数据库驱动:%s，按MySQL方言处理", driver)
+		return mysqlDialect{}
+	}
+	return d
+}
+
+// 设置构建器默认方言
+func SetDialect(d Dialect) {
+	dialectLock.Lock()
+	defer dialectLock.Unlock()
+	if d != nil {
+		defaultDialect = d
+	}
+}
+
+// 获取构建器默认方言
+func DefaultDialect() Dialect {
+	dialectLock.RLock()
+	defer dialectLock.RUnlock()
+	return defaultDialect
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return MySQL
+}
+
+func (mysqlDialect) Rebind(sql string) string {
+	return sql
+}
+
+func (mysqlDialect) Quote(name string) string {
+	return quoteIdent(name, "`", "`", false)
+}
+
+func (mysqlDialect) Like(left, right bool) string {
+	return fmt.Sprintf("CONCAT(%s)", strings.Join(likeParts(left, right), ", "))
+}
+
+func (mysqlDialect) Page(sql string, orderBy string, size, offset int64) (string, []interface{}) {
+	return limitOffset(sql, orderBy, size, offset)
+}
+
+func (d mysqlDialect) Insert(table string, columns []string, rows int) string {
+	return insertValues(d, table, columns, rows)
+}
+
+func (d mysqlDialect) Upsert(table string, columns []string, rows int, conflict []string, update []string) string {
+	sets := make([]string, 0, len(update))
+	for _, column := range update {
+		sets = append(sets, fmt.Sprintf("%s = values(%s)", d.Quote(column), d.Quote(column)))
+	}
+	if len(sets) == 0 {
+		// 无更新列时保持原记录不变
+		column := d.Quote(conflict[0])
+		sets = append(sets, fmt.Sprintf("%s = %s", column, column))
+	}
+	return fmt.Sprintf("%s on duplicate key update %s", insertValues(d, table, columns, rows), strings.Join(sets, ", "))
+}
+
+func (mysqlDialect) Savepoint(name string) (string, string, string) {
+	return savepoint(name, true)
+}
+
+// 预处理语句最多 65535 个参数
+func (mysqlDialect) BatchLimit() (int, int) {
+	return 65535, 0
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return Postgres
+}
+
+func (postgresDialect) Rebind(sql string) string {
+	return rebind(sql, func(i int) string { return "$" + strconv.Itoa(i) })
+}
+
+func (postgresDialect) Quote(name string) string {
+	return quoteIdent(name, `"`, `"`, false)
+}
+
+func (postgresDialect) Like(left, right bool) string {
+	return strings.Join(likeParts(left, right), " || ")
+}
+
+func (postgresDialect) Page(sql string, orderBy string, size, offset int64) (string, []interface{}) {
+	return limitOffset(sql, orderBy, size, offset)
+}
+
+func (d postgresDialect) Insert(table string, columns []string, rows int) string {
+	return insertValues(d, table, columns, rows)
+}
+
+func (d postgresDialect) Upsert(table string, columns []string, rows int, conflict []string, update []string) string {
+	return onConflict(d, table, columns, rows, conflict, update)
+}
+
+func (postgresDialect) Savepoint(name string) (string, string, string) {
+	return savepoint(name, true)
+}
+
+// 协议限制最多 65535 个参数
+func (postgresDialect) BatchLimit() (int, int) {
+	return 65535, 0
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return SQLite
+}
+
+func (sqliteDialect) Rebind(sql string) string {
+	return sql
+}
+
+func (sqliteDialect) Quote(name string) string {
+	return quoteIdent(name, `"`, `"`, false)
+}
+
+func (sqliteDialect) Like(left, right bool) string {
+	return strings.Join(likeParts(left, right), " || ")
+}
+
+func (sqliteDialect) Page(sql string, orderBy string, size, offset int64) (string, []interface{}) {
+	return limitOffset(sql, orderBy, size, offset)
+}
+
+func (d sqliteDialect) Insert(table string, columns []string, rows int) string {
+	return insertValues(d, table, columns, rows)
+}
+
+func (d sqliteDialect) Upsert(table string, columns []string, rows int, conflict []string, update []string) string {
+	return onConflict(d, table, columns, rows, conflict, update)
+}
+
+func (sqliteDialect) Savepoint(name string) (string, string, string) {
+	return savepoint(name, true)
+}
+
+// SQLITE_MAX_VARIABLE_NUMBER 默认 32766（3.32.0 及以上版本）
+func (sqliteDialect) BatchLimit() (int, int) {
+	return 32766, 0
+}
+
+// Oracle 12c 及以上版本；未加引号的标识符在 Oracle 中按大写处理，因此引用时统一转大写
+type oracleDialect struct{}
+
+func (oracleDialect) Name() string {
+	return Oracle
+}
+
+func (oracleDialect) Rebind(sql string) string {
+	return rebind(sql, func(i int) string { return ":" + strconv.Itoa(i) })
+}
+
+func (oracleDialect) Quote(name string) string {
+	return quoteIdent(name, `"`, `"`, true)
+}
+
+func (oracleDialect) Like(left, right bool) string {
+	return strings.Join(likeParts(left, right), " || ")
+}
+
+func (oracleDialect) Page(sql string, orderBy string, size, offset int64) (string, []interface{}) {
+	sql = fmt.Sprintf("%s %s OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", sql, orderBy)
+	return sql, []interface{}{offset, size}
+}
+
+// Oracle 不支持 values 多行，使用 insert ... select ... from dual union all
+func (d oracleDialect) Insert(table string, columns []string, rows int) string {
+	if rows <= 1 {
+		return insertValues(d, table, columns, rows)
+	}
+	return fmt.Sprintf("insert into %s(%s) %s", d.Quote(table), quoteJoin(d, columns), dualRows(d, columns, rows, false))
+}
+
+func (d oracleDialect) Upsert(table string, columns []string, rows int, conflict []string, update []string) string {
+	return merge(d, table, columns, fmt.Sprintf("(%s) s", dualRows(d, columns, rows, true)), conflict, update)
+}
+
+func (oracleDialect) Savepoint(name string) (string, string, string) {
+	return savepoint(name, false)
+}
+
+// 单条语句最多 65535 个绑定变量
+func (oracleDialect) BatchLimit() (int, int) {
+	return 65535, 0
+}
+
+// SQL Server 2012 及以上版本
+type sqlserverDialect struct{}
+
+func (sqlserverDialect) Name() string {
+	return SQLServer
+}
+
+func (sqlserverDialect) Rebind(sql string) string {
+	return rebind(sql, func(i int) string { return "@p" + strconv.Itoa(i) })
+}
+
+func (sqlserverDialect) Quote(name string) string {
+	return quoteIdent(name, "[", "]", false)
+}
+
+func (sqlserverDialect) Like(left, right bool) string {
+	return strings.Join(likeParts(left, right), " + ")
+}
+
+func (sqlserverDialect) Page(sql string, orderBy string, size, offset int64) (string, []interface{}) {
+	// OFFSET ... FETCH 必须配合 order by 使用
+	if strings.TrimSpace(orderBy) == "" {
+		orderBy = "order by (select null)"
+	}
+	sql = fmt.Sprintf("%s %s OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", sql, orderBy)
+	return sql, []interface{}{offset, size}
+}
+
+func (d sqlserverDialect) Insert(table string, columns []string, rows int) string {
+	return insertValues(d, table, columns, rows)
+}
+
+func (d sqlserverDialect) Upsert(table string, columns []string, rows int, conflict []string, update []string) string {
+	source := fmt.Sprintf("(values %s) s(%s)", valueRows(len(columns), rows), quoteJoin(d, columns))
+	return merge(d, table, columns, source, conflict, update) + ";"
+}
+
+func (sqlserverDialect) Savepoint(name string) (string, string, string) {
+	return "save transaction " + name, "rollback transaction " + name, ""
+}
+
+// RPC 最多 2100 个参数，values 最多 1000 行
+func (sqlserverDialect) BatchLimit() (int, int) {
+	return 2100, 1000
+}
+
+func savepoint(name string, release bool) (string, string, string) {
+	if !release {
+		return "savepoint " + name, "rollback to savepoint " + name, ""
+	}
+	return "savepoint " + name, "rollback to savepoint " + name, "release savepoint " + name
+}
+
+func limitOffset(sql string, orderBy string, size, offset int64) (string, []interface{}) {
+	sql = fmt.Sprintf("%s %s LIMIT ? OFFSET ?", sql, orderBy)
+	return sql, []interface{}{size, offset}
+}
+
+func quoteJoin(d Dialect, columns []string) string {
+	cols := make([]string, 0, len(columns))
+	for _, column := range columns {
+		cols = append(cols, d.Quote(column))
+	}
+	return strings.Join(cols, ", ")
+}
+
+// (?, ?), (?, ?)
+func valueRows(columns int, rows int) string {
+	row := "(" + Placeholders(columns) + ")"
+	items := make([]string, rows)
+	for i := range items {
+		items[i] = row
+	}
+	return strings.Join(items, ", ")
+}
+
+func insertValues(d Dialect, table string, columns []string, rows int) string {
+	if rows < 1 {
+		rows = 1
+	}
+	return fmt.Sprintf("insert into %s(%s) values %s", d.Quote(table), quoteJoin(d, columns), valueRows(len(columns), rows))
+}
+
+// select ?, ? from dual union all select ?, ? from dual；alias 为 true 时为每列指定别名
+func dualRows(d Dialect, columns []string, rows int, alias bool) string {
+	fields := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if alias {
+			fields = append(fields, "? "+d.Quote(column))
+		} else {
+			fields = append(fields, "?")
+		}
+	}
+	row := fmt.Sprintf("select %s from dual", strings.Join(fields, ", "))
+	items := make([]string, rows)
+	for i := range items {
+		items[i] = row
+	}
+	return strings.Join(items, " union all ")
+}
+
+// PostgreSQL / SQLite: insert ... on conflict (...) do update set ...
+func onConflict(d Dialect, table string, columns []string, rows int, conflict []string, update []string) string {
+	sql := fmt.Sprintf("%s on conflict (%s)", insertValues(d, table, columns, rows), quoteJoin(d, conflict))
+	if len(update) == 0 {
+		return sql + " do nothing"
+	}
+	sets := make([]string, 0, len(update))
+	for _, column := range update {
+		sets = append(sets, fmt.Sprintf("%s = excluded.%s", d.Quote(column), d.Quote(column)))
+	}
+	return fmt.Sprintf("%s do update set %s", sql, strings.Join(sets, ", "))
+}
+
+// 开启多租户时的插入或更新语句：更新列不含租户列，冲突记录属于其它租户时不更新；
+// tenant 为租户列，自定义方言不支持时返回错误
+func TenantUpsert(d Dialect, table string, columns []string, rows int, conflict []string, update []string, tenant string) (string, error) {
+	list := make([]string, 0, len(update))
+	for _, column := range update {
+		if !strings.EqualFold(column, tenant) {
+			list = append(list, column)
+		}
+	}
+	update = list
+	switch d.(type) {
+	case mysqlDialect:
+		guard := fmt.Sprintf("%s = values(%s)", d.Quote(tenant), d.Quote(tenant))
+		sets := make([]string, 0, len(update))
+		for _, column := range update {
+			sets = append(sets, fmt.Sprintf("%s = if(%s, values(%s), %s)", d.Quote(column), guard, d.Quote(column), d.Quote(column)))
+		}
+		if len(sets) == 0 {
+			column := d.Quote(conflict[0])
+			sets = append(sets, fmt.Sprintf("%s = %s", column, column))
+		}
+		return fmt.Sprintf("%s on duplicate key update %s", insertValues(d, table, columns, rows), strings.Join(sets, ", ")), nil
+	case postgresDialect, sqliteDialect:
+		sql := onConflict(d, table, columns, rows, conflict, update)
+		if len(update) == 0 {
+			return sql, nil
+		}
+		return fmt.Sprintf("%s where %s.%s = excluded.%s", sql, d.Quote(table), d.Quote(tenant), d.Quote(tenant)), nil
+	case oracleDialect:
+		return mergeTenant(d, table, columns, fmt.Sprintf("(%s) s", dualRows(d, columns, rows, true)), conflict, update, tenant), nil
+	case sqlserverDialect:
+		source := fmt.Sprintf("(values %s) s(%s)", valueRows(len(columns), rows), quoteJoin(d, columns))
+		return mergeTenant(d, table, columns, source, conflict, update, tenant) + ";", nil
+	}
+	return "", errors.New("方言不支持多租户插入或更新: " + d.Name())
+}
+
+// Oracle / SQL Server: merge into ... using source s on (...)
+func merge(d Dialect, table string, columns []string, source string, conflict []string, update []string) string {
+	return mergeTenant(d, table, columns, source, conflict, update, "")
+}
+
+// tenant 不为空时只更新同一租户的记录
+func mergeTenant(d Dialect, table string, columns []string, source string, conflict []string, update []string, tenant string) string {
+	ons := make([]string, 0, len(conflict))
+	for _, column := range conflict {
+		ons = append(ons, fmt.Sprintf("t.%s = s.%s", d.Quote(column), d.Quote(column)))
+	}
+	sql := fmt.Sprintf("merge into %s t using %s on (%s)", d.Quote(table), source, strings.Join(ons, " and "))
+	if len(update) > 0 {
+		sets := make([]string, 0, len(update))
+		for _, column := range update {
+			sets = append(sets, fmt.Sprintf("t.%s = s.%s", d.Quote(column), d.Quote(column)))
+		}
+		switch {
+		case tenant == "":
+			sql += " when matched then update set " + strings.Join(sets, ", ")
+		case d.Name() == Oracle:
+			sql += fmt.Sprintf(" when matched then update set %s where t.%s = s.%s", strings.Join(sets, ", "), d.Quote(tenant), d.Quote(tenant))
+		default:
+			sql += fmt.Sprintf(" when matched and t.%s = s.%s then update set %s", d.Quote(tenant), d.Quote(tenant), strings.Join(sets, ", "))
+		}
+	}
+	vals := make([]string, 0, len(columns))
+	for _, column := range columns {
+		vals = append(vals, "s."+d.Quote(column))
+	}
+	return fmt.Sprintf("%s when not matched then insert (%s) values (%s)", sql, quoteJoin(d, columns), strings.Join(vals, ", "))
+}
+
+func likeParts(left, right bool) []string {
+	parts := make([]string, 0, 3)
+	if left {
+		parts = append(parts, "'%'")
+	}
+	parts = append(parts, "?")
+	if right {
+		parts = append(parts, "'%'")
+	}
+	return parts
+}
+
+// 替换SQL中的 ? 占位符，跳过字符串及引用标识符中的内容
+func rebind(sql string, placeholder func(i int) string) string {
+	if !strings.Contains(sql, "?") {
+		return sql
+	}
+	var b strings.Builder
+	b.Grow(len(sql) + 16)
+	var quote byte
+	n := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			b.WriteByte(c)
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			b.WriteByte(c)
+		case c == '?':
+			n++
+			b.WriteString(placeholder(n))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// 引用标识符，已引用、通配符或表达式不做处理
+func quoteIdent(name string, left, right string, upper bool) string {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "*" || strings.ContainsAny(name, "()'\" `[") {
+		return name
+	}
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		if p == "*" {
+			continue
+		}
+		if upper {
+			p = strings.ToUpper(p)
+		}
+		parts[i] = left + p + right
+	}
+	return strings.Join(parts, ".")
+}
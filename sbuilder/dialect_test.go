@@ -0,0 +1,76 @@
+package sbuilder
+
+import (
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	sql := "select * from t where a = ? and b like '%?%' and c in(?, ?)"
+	cases := map[string]string{
+		"mysql":     sql,
+		"postgres":  "select * from t where a = $1 and b like '%?%' and c in($2, $3)",
+		"godror":    "select * from t where a = :1 and b like '%?%' and c in(:2, :3)",
+		"sqlserver": "select * from t where a = @p1 and b like '%?%' and c in(@p2, @p3)",
+	}
+	for driver, want := range cases {
+		if got := GetDialect(driver).Rebind(sql); got != want {
+			t.Errorf("%s: got %s, want %s", driver, got, want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	cases := []struct {
+		driver string
+		name   string
+		want   string
+	}{
+		{"mysql", "user_name", "`user_name`"},
+		{"postgres", "a.user_name", `"a"."user_name"`},
+		{"oracle", "user_name", `"USER_NAME"`},
+		{"mssql", "a.*", "[a].*"},
+		{"mysql", "count(1)", "count(1)"},
+	}
+	for _, c := range cases {
+		if got := GetDialect(c.driver).Quote(c.name); got != c.want {
+			t.Errorf("%s: got %s, want %s", c.driver, got, c.want)
+		}
+	}
+}
+
+func TestLike(t *testing.T) {
+	b := Builder("select * from t").WithDialect(GetDialect("postgres"))
+	b.Like("name", "a")
+	b.LikeLeft("code", "b")
+	sql, values := b.Build()
+	want := "select * from t where 1=1  and name like '%' || ? || '%'  and code not like '%' || ? "
+	if sql != want || len(values) != 2 {
+		t.Errorf("got %q %v", sql, values)
+	}
+}
+
+func TestPage(t *testing.T) {
+	sql, values := GetDialect("sqlserver").Page("select * from t", "", 10, 20)
+	if sql != "select * from t order by (select null) OFFSET ? ROWS FETCH NEXT ? ROWS ONLY" {
+		t.Errorf("got %s", sql)
+	}
+	if values[0] != int64(20) || values[1] != int64(10) {
+		t.Errorf("got %v", values)
+	}
+}
+
+func TestTenantUpsert(t *testing.T) {
+	for _, v := range []struct {
+		driver string
+		sql    string
+	}{
+		{"mysql", "insert into `doc`(`id`, `name`, `tenant_id`) values (?, ?, ?) on duplicate key update `name` = if(`tenant_id` = values(`tenant_id`), values(`name`), `name`)"},
+		{"sqlite3", `insert into "doc"("id", "name", "tenant_id") values (?, ?, ?) on conflict ("id") do update set "name" = excluded."name" where "doc"."tenant_id" = excluded."tenant_id"`},
+		{"sqlserver", "merge into [doc] t using (values (?, ?, ?)) s([id], [name], [tenant_id]) on (t.[id] = s.[id]) when matched and t.[tenant_id] = s.[tenant_id] then update set t.[name] = s.[name] when not matched then insert ([id], [name], [tenant_id]) values (s.[id], s.[name], s.[tenant_id]);"},
+	} {
+		sql, err := TenantUpsert(GetDialect(v.driver), "doc", []string{"id", "name", "tenant_id"}, 1, []string{"id"}, []string{"name", "tenant_id"}, "tenant_id")
+		if err != nil || sql != v.sql {
+			t.Fatal(v.driver, sql, err)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package sbuilder
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// 加密算法：名称 -> 分组密码
+	ciphers = map[string]func(key []byte) (cipher.Block, error){
+		"aes": aes.NewCipher,
+		"sm4": newSM4,
+	}
+	// 当前加密使用的密钥ID
+	encryptKey string
+	// 密钥ID -> 密钥，解密时按密文中的密钥ID选择
+	encryptKeys map[string][]byte
+	// 密钥ID -> 确定性加密生成 nonce 的 HMAC 密钥（由加密密钥派生）
+	nonceKeys map[string][]byte
+	// 表名 -> 加密列，供只有表名的构建器使用
+	encryptTables = make(map[string]map[string]*encryptMeta)
+	encryptLock   sync.RWMutex
+)
+
+// 加密字段元数据：encrypt:"aes"、encrypt:"sm4"、encrypt:"aes,deterministic"
+type encryptMeta struct {
+	algo string
+	// 确定性加密：相同明文密文相同，可用于等值查询
+	deterministic bool
+}
+
+func parseEncrypt(tag string) *encryptMeta {
+	if tag == "" || tag == "-" {
+		return nil
+	}
+	items := strings.Split(tag, ",")
+	meta := &encryptMeta{algo: strings.ToLower(strings.TrimSpace(items[0]))}
+	for _, v := range items[1:] {
+		if strings.TrimSpace(v) == "deterministic" {
+			meta.deterministic = true
+		}
+	}
+	return meta
+}
+
+// 注册加密算法，name 为 encrypt tag 中的算法名，分组长度需为16字节
+func RegisterCipher(name string, block func(key []byte) (cipher.Block, error)) {
+	encryptLock.Lock()
+	defer encryptLock.Unlock()
+	ciphers[strings.ToLower(name)] = block
+}
+
+// 设置加密密钥：active 为加密使用的密钥ID，keys 为全部密钥（包括轮换前的旧密钥，用于解密）
+func SetEncryptKeys(active string, keys map[string][]byte) error {
+	if len(keys) > 0 {
+		if _, ok := keys[active]; !ok {
+			return errors.New("加密密钥不存在: " + active)
+		}
+	}
+	for id := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return errors.New("加密密钥ID不能为空或包含冒号: " + id)
+		}
+	}
+	nonces := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("sc-go deterministic nonce"))
+		nonces[id] = mac.Sum(nil)
+	}
+	encryptLock.Lock()
+	defer encryptLock.Unlock()
+	encryptKey = active
+	encryptKeys = keys
+	nonceKeys = nonces
+	return nil
+}
+
+// 使用当前密钥加密，返回 密钥ID:base64(nonce+密文)；deterministic 为true时相同明文密文相同
+func Encrypt(algo string, plain string, deterministic bool) (string, error) {
+	encryptLock.RLock()
+	id := encryptKey
+	encryptLock.RUnlock()
+	return encryptWith(algo, id, plain, deterministic)
+}
+
+// 使用密文中的密钥ID解密；不是密文（未加密的历史数据）时原样返回
+func Decrypt(algo string, value string) (string, error) {
+	i := strings.Index(value, ":")
+	if i <= 0 {
+		return value, nil
+	}
+	encryptLock.RLock()
+	_, ok := encryptKeys[value[:i]]
+	encryptLock.RUnlock()
+	if !ok {
+		return value, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value[i+1:])
+	if err != nil {
+		return value, nil
+	}
+	aead, err := newAEAD(algo, value[:i])
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("密文长度错误")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("解密失败: %w", err)
+	}
+	return string(plain), nil
+}
+
+func encryptWith(algo string, id string, plain string, deterministic bool) (string, error) {
+	aead, err := newAEAD(algo, id)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if deterministic {
+		encryptLock.RLock()
+		mac := hmac.New(sha256.New, nonceKeys[id])
+		encryptLock.RUnlock()
+		mac.Write([]byte(plain))
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	data := aead.Seal(nonce, nonce, []byte(plain), nil)
+	return id + ":" + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// 按算法及密钥ID创建 GCM 加密对象
+func newAEAD(algo string, id string) (cipher.AEAD, error) {
+	encryptLock.RLock()
+	newBlock := ciphers[algo]
+	key, ok := encryptKeys[id]
+	encryptLock.RUnlock()
+	if newBlock == nil {
+		return nil, errors.New("不支持的加密算法: " + algo)
+	}
+	if !ok {
+		return nil, errors.New("加密密钥未配置: " + id)
+	}
+	block, err := newBlock(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// 加密字段值：插入、更新时使用当前密钥；查询时使用确定性加密，有多个密钥时生成全部密钥的密文用于 in 查询
+func encryptValue(meta *encryptMeta, value interface{}, query bool) (interface{}, error) {
+	plain, ok := value.(string)
+	if p, isPtr := value.(*string); isPtr && p != nil {
+		plain, ok = *p, true
+	}
+	if !ok {
+		return value, nil
+	}
+	if !query {
+		return Encrypt(meta.algo, plain, meta.deterministic)
+	}
+	encryptLock.RLock()
+	ids := make([]string, 0, len(encryptKeys))
+	for id := range encryptKeys {
+		if id != encryptKey {
+			ids = append(ids, id)
+		}
+	}
+	active := encryptKey
+	encryptLock.RUnlock()
+	sort.Strings(ids)
+	ids = append([]string{active}, ids...)
+	result := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		v, err := encryptWith(meta.algo, id, plain, true)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	if len(result) == 1 {
+		return result[0], nil
+	}
+	return result, nil
+}
+
+// 加密查询条件值：只有确定性加密字段可作为等值（eq、in）条件，in 条件的每个值分别加密
+func encryptCondition(meta *encryptMeta, keyword string, value interface{}) (interface{}, error) {
+	if !meta.deterministic {
+		return nil, errors.New("非确定性加密字段不能作为查询条件")
+	}
+	if keyword != Eq && keyword != In {
+		return nil, errors.New("加密字段只支持等值查询: " + keyword)
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return encryptValue(meta, value, true)
+	}
+	result := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		v, err := encryptValue(meta, rv.Index(i).Interface(), true)
+		if err != nil {
+			return nil, err
+		}
+		if vs, ok := v.([]interface{}); ok {
+			result = append(result, vs...)
+		} else {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// 加密字段值，失败时记录到 info.Err；查询有多个密钥的密文时等值条件改为 in
+func encryptField(info *StructInfo, item *FieldInfo, meta *encryptMeta, query bool) {
+	var value interface{}
+	var err error
+	if query {
+		value, err = encryptCondition(meta, item.TagKeyword, item.Value)
+	} else {
+		value, err = encryptValue(meta, item.Value, false)
+	}
+	if err != nil {
+		log.Printf("加密字段失败 %s: %v", item.Name, err)
+		if info.Err == nil {
+			info.Err = fmt.Errorf("加密字段失败 %s: %w", item.Name, err)
+		}
+		return
+	}
+	item.Value = value
+	if _, ok := value.([]interface{}); ok && item.TagKeyword == Eq {
+		item.TagKeyword = In
+	}
+}
+
+// 登记实体的加密列，供只有表名的构建器（UpdateBuilder、InsertBuilder）加密写入值；实体解析时自动登记
+func RegisterEncrypt(objs ...interface{}) {
+	for _, obj := range objs {
+		v := reflect.ValueOf(obj)
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Struct {
+			registerEncryptTable(tableName(v, getStructMeta(v.Type())), getStructMeta(v.Type()))
+		}
+	}
+}
+
+func registerEncryptTable(table string, meta *structMeta) {
+	if !meta.encrypted {
+		return
+	}
+	encryptLock.RLock()
+	_, ok := encryptTables[table]
+	encryptLock.RUnlock()
+	if ok {
+		return
+	}
+	columns := make(map[string]*encryptMeta)
+	for _, f := range meta.fields {
+		if f.encrypt != nil {
+			columns[f.TagDB] = f.encrypt
+		}
+	}
+	encryptLock.Lock()
+	defer encryptLock.Unlock()
+	encryptTables[table] = columns
+}
+
+// 按表名加密写入值，不是加密列时原样返回
+func encryptTableValue(table string, column string, value interface{}) (interface{}, error) {
+	encryptLock.RLock()
+	meta := encryptTables[table][column]
+	encryptLock.RUnlock()
+	if meta == nil {
+		return value, nil
+	}
+	v, err := encryptValue(meta, value, false)
+	if err != nil {
+		return nil, fmt.Errorf("加密字段失败 %s: %w", column, err)
+	}
+	return v, nil
+}
+
+// 加密写入值：column 为 obj 的字段名或列名，不是加密列时返回 false
+func EncryptValue(obj interface{}, column string, value interface{}) (interface{}, bool, error) {
+	f := encryptFieldOf(obj, column)
+	if f == nil {
+		return value, false, nil
+	}
+	v, err := encryptValue(f.encrypt, value, false)
+	return v, true, err
+}
+
+func encryptFieldOf(obj interface{}, column string) *fieldMeta {
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	meta := getStructMeta(t)
+	if !meta.encrypted {
+		return nil
+	}
+	for i, f := range meta.fields {
+		if f.encrypt != nil && (f.Name == column || f.TagDB == column) {
+			return &meta.fields[i]
+		}
+	}
+	return nil
+}
+
+// 加密等值查询条件值：column 为 obj 的字段名或列名，不是加密列时返回 false；有多个密钥时返回全部密文切片；
+// 非确定性加密字段返回错误
+func EncryptQuery(obj interface{}, column string, value interface{}) (interface{}, bool, error) {
+	f := encryptFieldOf(obj, column)
+	if f == nil {
+		return value, false, nil
+	}
+	v, err := encryptCondition(f.encrypt, Eq, value)
+	return v, true, err
+}
+
+// 解密查询结果中的加密字段，data 为结构体指针或结构体切片指针
+func DecryptFields(data interface{}) error {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return cryptFields(v, false)
+}
+
+// 加密对象中的加密字段（直接修改字段值），用于不经过 GetField 的写入，如 gorm
+func EncryptFields(data interface{}) error {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return cryptFields(v, true)
+}
+
+func cryptFields(v reflect.Value, encrypt bool) error {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		elem := v.Type().Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct || !getStructMeta(elem).encrypted {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			for item.Kind() == reflect.Ptr && !item.IsNil() {
+				item = item.Elem()
+			}
+			if err := cryptFields(item, encrypt); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		meta := getStructMeta(v.Type())
+		if !meta.encrypted {
+			return nil
+		}
+		for _, f := range meta.fields {
+			if f.encrypt == nil {
+				continue
+			}
+			fv := v.FieldByIndex(f.index)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() != reflect.String || fv.String() == "" || !fv.CanSet() {
+				continue
+			}
+			var value string
+			var err error
+			if encrypt {
+				value, err = Encrypt(f.encrypt.algo, fv.String(), f.encrypt.deterministic)
+			} else {
+				value, err = Decrypt(f.encrypt.algo, fv.String())
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+			fv.SetString(value)
+		}
+	}
+	return nil
+}
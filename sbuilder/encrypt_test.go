@@ -0,0 +1,82 @@
+package sbuilder
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestSM4(t *testing.T) {
+	key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	block, _ := newSM4(key)
+	dst := make([]byte, 16)
+	block.Encrypt(dst, key)
+	if hex.EncodeToString(dst) != "681edf34d206965e86b3e94f536e4246" {
+		t.Fatal(hex.EncodeToString(dst))
+	}
+	block.Decrypt(dst, dst)
+	if hex.EncodeToString(dst) != "0123456789abcdeffedcba9876543210" {
+		t.Fatal(hex.EncodeToString(dst))
+	}
+}
+
+type encryptDemo struct {
+	Id    string `db:"id,primary_key"`
+	Phone string `db:"phone" encrypt:"aes,deterministic"`
+	Card  string `db:"card" encrypt:"sm4"`
+	Name  string `db:"name" encrypt:"aes,deterministic" keyword:"like"`
+}
+
+func TestEncrypt(t *testing.T) {
+	SetEncryptKeys("k1", map[string][]byte{"k1": []byte("0123456789abcdef")})
+	defer SetEncryptKeys("", nil)
+	obj := &encryptDemo{Id: "1", Phone: "13800000000", Card: "110101"}
+	info := GetField(obj, 1)
+	if info.Err != nil || info.Fields[1].Value == obj.Phone || info.Fields[2].Value == obj.Card {
+		t.Fatal(info.Err, info.Fields)
+	}
+	query := GetField(&encryptDemo{Phone: "13800000000"}, 0)
+	if query.Fields[0].Value != info.Fields[1].Value {
+		t.Fatal("确定性加密密文不一致", query.Fields[0].Value)
+	}
+	// 非确定性加密字段及非等值条件无法匹配密文
+	if GetField(&encryptDemo{Card: "110101"}, 0).Err == nil || GetField(&encryptDemo{Name: "张"}, 0).Err == nil {
+		t.Fatal("加密字段条件未报错")
+	}
+	row := encryptDemo{Phone: info.Fields[1].Value.(string), Card: info.Fields[2].Value.(string)}
+	rows := []encryptDemo{row}
+	if err := DecryptFields(&rows); err != nil || rows[0].Phone != obj.Phone || rows[0].Card != obj.Card {
+		t.Fatal(err, rows)
+	}
+	// 轮换密钥后旧密文可解密，等值查询使用全部密钥的密文
+	SetEncryptKeys("k2", map[string][]byte{"k1": []byte("0123456789abcdef"), "k2": []byte("fedcba9876543210")})
+	if err := DecryptFields(&row); err != nil || row.Phone != obj.Phone {
+		t.Fatal(err, row)
+	}
+	query = GetField(&encryptDemo{Phone: "13800000000"}, 0)
+	if values, ok := query.Fields[0].Value.([]interface{}); !ok || len(values) != 2 || query.Fields[0].TagKeyword != In {
+		t.Fatal(query.Fields[0])
+	}
+}
+
+func TestEncryptBuilder(t *testing.T) {
+	SetEncryptKeys("k1", map[string][]byte{"k1": []byte("0123456789abcdef")})
+	defer SetEncryptKeys("", nil)
+	RegisterEncrypt(&encryptDemo{})
+	update := Update("encrypt_demo").Set("phone", "138").SetExpr("version = version + ?", 1).Set("id", "1")
+	insert := InsertInto("encrypt_demo").Columns("id", "card").Values("1", "110")
+	if err := update.Encrypt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := insert.Encrypt(); err != nil {
+		t.Fatal(err)
+	}
+	_, values := update.Build()
+	phone, _ := Decrypt("aes", values[0].(string))
+	if values[0] == "138" || phone != "138" || values[1] != 1 || values[2] != "1" {
+		t.Fatal(values)
+	}
+	_, values = insert.Build()
+	if card, _ := Decrypt("sm4", values[1].(string)); values[1] == "110" || card != "110" {
+		t.Fatal(values)
+	}
+}
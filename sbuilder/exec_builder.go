@@ -0,0 +1,283 @@
+package sbuilder
+
+import (
+	"strings"
+)
+
+// 插入构建器：按方言生成单行或多行 insert 语句
+type InsertBuilder struct {
+	dialect      Dialect
+	table        string
+	columns      []string
+	rows         [][]interface{}
+	ignoreTenant bool
+	encrypted    bool
+}
+
+// 创建插入构建器
+func InsertInto(table string) *InsertBuilder {
+	return &InsertBuilder{dialect: DefaultDialect(), table: table}
+}
+
+// 指定当前构建器使用的数据库方言
+func (m *InsertBuilder) WithDialect(d Dialect) *InsertBuilder {
+	if d != nil {
+		m.dialect = d
+	}
+	return m
+}
+
+// 获取当前构建器使用的数据库方言
+func (m *InsertBuilder) GetDialect() Dialect {
+	return m.dialect
+}
+
+// 不填充租户列
+func (m *InsertBuilder) IgnoreTenant() *InsertBuilder {
+	m.ignoreTenant = true
+	return m
+}
+
+// 插入列
+func (m *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	m.columns = append(m.columns, columns...)
+	return m
+}
+
+// 追加一行数据，值按列顺序排列
+func (m *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	m.rows = append(m.rows, values)
+	return m
+}
+
+// 按表名登记的加密列（见 RegisterEncrypt）加密插入值，只执行一次
+func (m *InsertBuilder) Encrypt() error {
+	if m.encrypted {
+		return nil
+	}
+	rows := make([][]interface{}, 0, len(m.rows))
+	for _, row := range m.rows {
+		values := append(make([]interface{}, 0, len(row)), row...)
+		for i, column := range m.columns {
+			if i >= len(values) {
+				break
+			}
+			v, err := encryptTableValue(m.table, column, values[i])
+			if err != nil {
+				return err
+			}
+			values[i] = v
+		}
+		rows = append(rows, values)
+	}
+	m.rows = rows
+	m.encrypted = true
+	return nil
+}
+
+// 生成SQL及参数
+func (m *InsertBuilder) Build() (string, []interface{}) {
+	columns := m.columns
+	column, tenant := m.tenant()
+	if tenant != nil {
+		columns = append(append(make([]string, 0, len(columns)+1), columns...), column)
+	}
+	values := make([]interface{}, 0, len(columns)*len(m.rows))
+	for _, row := range m.rows {
+		values = append(values, row...)
+		if tenant != nil {
+			values = append(values, tenant)
+		}
+	}
+	return m.dialect.Insert(m.table, columns, len(m.rows)), values
+}
+
+// 需要填充的租户列及当前租户ID：已指定租户列或不处理租户时返回空
+func (m *InsertBuilder) tenant() (string, interface{}) {
+	if m.ignoreTenant {
+		return "", nil
+	}
+	column, value := Tenant(m.table)
+	for _, v := range m.columns {
+		if v == column {
+			return "", nil
+		}
+	}
+	return column, value
+}
+
+// 需处理租户但没有当前租户
+func (m *InsertBuilder) TenantMissing() bool {
+	column, value := m.tenant()
+	return column != "" && value == nil
+}
+
+// 更新构建器：Set 设置的列无论是否为零值均会更新
+type UpdateBuilder struct {
+	dialect      Dialect
+	table        string
+	sets         []setClause
+	values       []interface{}
+	where        []Condition
+	ignoreTenant bool
+	encrypted    bool
+}
+
+// 更新列（column 不为空）或更新表达式
+type setClause struct {
+	column string
+	expr   string
+	// 列值在 values 中的位置
+	index int
+}
+
+// 创建更新构建器
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{dialect: DefaultDialect(), table: table}
+}
+
+// 指定当前构建器使用的数据库方言
+func (m *UpdateBuilder) WithDialect(d Dialect) *UpdateBuilder {
+	if d != nil {
+		m.dialect = d
+	}
+	return m
+}
+
+// 获取当前构建器使用的数据库方言
+func (m *UpdateBuilder) GetDialect() Dialect {
+	return m.dialect
+}
+
+// 更新列值
+func (m *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	m.sets = append(m.sets, setClause{column: column, index: len(m.values)})
+	m.values = append(m.values, value)
+	return m
+}
+
+// 更新表达式：SetExpr("count = count + ?", 1)
+func (m *UpdateBuilder) SetExpr(expr string, values ...interface{}) *UpdateBuilder {
+	m.sets = append(m.sets, setClause{expr: expr})
+	m.values = append(m.values, values...)
+	return m
+}
+
+// 追加更新条件，多次调用之间为 and 关系
+func (m *UpdateBuilder) Where(conds ...Condition) *UpdateBuilder {
+	m.where = append(m.where, conds...)
+	return m
+}
+
+// 不追加租户条件
+func (m *UpdateBuilder) IgnoreTenant() *UpdateBuilder {
+	m.ignoreTenant = true
+	return m
+}
+
+// 按表名登记的加密列（见 RegisterEncrypt）加密 Set 的值，只执行一次
+func (m *UpdateBuilder) Encrypt() error {
+	if m.encrypted {
+		return nil
+	}
+	for _, v := range m.sets {
+		if v.column == "" {
+			continue
+		}
+		value, err := encryptTableValue(m.table, v.column, m.values[v.index])
+		if err != nil {
+			return err
+		}
+		m.values[v.index] = value
+	}
+	m.encrypted = true
+	return nil
+}
+
+// 是否有非空的更新条件（不含租户条件）
+func (m *UpdateBuilder) HasWhere() bool {
+	where, _ := (&group{"and", m.where}).clause(m.dialect)
+	return where != ""
+}
+
+// 生成SQL及参数
+func (m *UpdateBuilder) Build() (string, []interface{}) {
+	sets := make([]string, 0, len(m.sets))
+	for _, v := range m.sets {
+		if v.column != "" {
+			sets = append(sets, m.dialect.Quote(v.column)+" = ?")
+		} else {
+			sets = append(sets, v.expr)
+		}
+	}
+	sql := "update " + m.dialect.Quote(m.table) + " set " + strings.Join(sets, ", ")
+	values := append(make([]interface{}, 0, len(m.values)), m.values...)
+	if where, vs := whereClause(m.dialect, m.where, m.table, m.ignoreTenant); where != "" {
+		sql += " where " + where
+		values = append(values, vs...)
+	}
+	return sql, values
+}
+
+// 删除构建器
+type DeleteBuilder struct {
+	dialect      Dialect
+	table        string
+	where        []Condition
+	ignoreTenant bool
+}
+
+// 创建删除构建器
+func DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{dialect: DefaultDialect(), table: table}
+}
+
+// 指定当前构建器使用的数据库方言
+func (m *DeleteBuilder) WithDialect(d Dialect) *DeleteBuilder {
+	if d != nil {
+		m.dialect = d
+	}
+	return m
+}
+
+// 获取当前构建器使用的数据库方言
+func (m *DeleteBuilder) GetDialect() Dialect {
+	return m.dialect
+}
+
+// 追加删除条件，多次调用之间为 and 关系
+func (m *DeleteBuilder) Where(conds ...Condition) *DeleteBuilder {
+	m.where = append(m.where, conds...)
+	return m
+}
+
+// 不追加租户条件
+func (m *DeleteBuilder) IgnoreTenant() *DeleteBuilder {
+	m.ignoreTenant = true
+	return m
+}
+
+// 是否有非空的删除条件（不含租户条件）
+func (m *DeleteBuilder) HasWhere() bool {
+	where, _ := (&group{"and", m.where}).clause(m.dialect)
+	return where != ""
+}
+
+// 生成SQL及参数
+func (m *DeleteBuilder) Build() (string, []interface{}) {
+	sql := "delete from " + m.dialect.Quote(m.table)
+	where, values := whereClause(m.dialect, m.where, m.table, m.ignoreTenant)
+	if where != "" {
+		sql += " where " + where
+	}
+	return sql, values
+}
+
+// 更新、删除条件，追加租户条件
+func whereClause(d Dialect, where []Condition, table string, ignoreTenant bool) (string, []interface{}) {
+	conds := append(make([]Condition, 0, len(where)+1), where...)
+	if !ignoreTenant {
+		conds = append(conds, tenantCondition(table, "", d))
+	}
+	return (&group{"and", conds}).clause(d)
+}
@@ -0,0 +1,29 @@
+package sbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExecBuilder(t *testing.T) {
+	d := GetDialect(MySQL)
+	sql, values := Update("sys_user").WithDialect(d).Set("name", "").SetExpr("login_count = login_count + ?", 1).
+		Where(Col("id").In([]string{"1", "2"}), Col("state").Eq("")).Build()
+	if sql != "update `sys_user` set `name` = ?, login_count = login_count + ? where id in(?, ?)" {
+		t.Errorf("update %s", sql)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"", 1, "1", "2"}) {
+		t.Errorf("update values %v", values)
+	}
+	if Update("sys_user").Set("name", "a").Where(Col("id").Eq("")).HasWhere() {
+		t.Errorf("empty where")
+	}
+	sql, values = DeleteFrom("sys_user").WithDialect(d).Where(Col("id").Eq("1")).Build()
+	if sql != "delete from `sys_user` where id = ?" || len(values) != 1 {
+		t.Errorf("delete %s %v", sql, values)
+	}
+	sql, values = InsertInto("sys_user").WithDialect(d).Columns("id", "name").Values("1", "a").Values("2", "b").Build()
+	if sql != "insert into `sys_user`(`id`, `name`) values (?, ?), (?, ?)" || len(values) != 4 {
+		t.Errorf("insert %s %v", sql, values)
+	}
+}
@@ -0,0 +1,410 @@
+package sbuilder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	// 命名SQL：命名空间.ID -> 语句
+	namedStatements = make(map[string]*namedNode)
+	namedLock       sync.RWMutex
+)
+
+// 模板节点：name 为空时为文本
+type namedNode struct {
+	name      string
+	attrs     map[string]string
+	text      string
+	children  []*namedNode
+	namespace string
+	// 来源文件，重新加载同一文件时替换其中的语句
+	source string
+}
+
+// 从文件系统加载SQL模板（MyBatis风格的xml），pattern 为 fs.Glob 匹配模式，如 sql/*.xml
+func LoadNamed(fsys fs.FS, pattern string) error {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		bs, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		if err := parseNamed(file, bs); err != nil {
+			return fmt.Errorf("解析SQL模板失败 %s: %w", file, err)
+		}
+	}
+	log.Printf("加载SQL模板文件：%d", len(files))
+	return nil
+}
+
+// 解析SQL模板：
+//
+//	<mapper namespace="user">
+//	  <sql id="columns">id, name</sql>
+//	  <select id="findActive">
+//	    select <include refid="columns"/> from sys_user
+//	    <where>
+//	      <if test="name != null and name != ''">and name like #{name}</if>
+//	      <if test="ids != null">and id in <foreach collection="ids" item="id" open="(" separator="," close=")">#{id}</foreach></if>
+//	    </where>
+//	  </select>
+//	</mapper>
+//
+// 重复解析相同内容时不报错，不同内容中的语句ID重复时返回错误
+func ParseNamed(data []byte) error {
+	sum := sha256.Sum256(data)
+	return parseNamed(hex.EncodeToString(sum[:]), data)
+}
+
+// 解析SQL模板，source 为来源：先移除同一来源已加载的语句，再检查与其它来源的语句是否重复
+func parseNamed(source string, data []byte) error {
+	root, err := parseNamedXML(data)
+	if err != nil {
+		return err
+	}
+	if root.name != "mapper" {
+		return errors.New("SQL模板根节点需为 mapper")
+	}
+	namespace := root.attrs["namespace"]
+	items := make(map[string]*namedNode)
+	for _, child := range root.children {
+		if child.name == "" {
+			continue
+		}
+		if child.name != "select" && child.name != "sql" {
+			return fmt.Errorf("不支持的节点: %s", child.name)
+		}
+		id := child.attrs["id"]
+		if id == "" {
+			return fmt.Errorf("%s 节点缺少 id", child.name)
+		}
+		if namespace != "" {
+			id = namespace + "." + id
+		}
+		if _, ok := items[id]; ok {
+			return fmt.Errorf("SQL模板重复: %s", id)
+		}
+		child.namespace = namespace
+		child.source = source
+		items[id] = child
+	}
+	namedLock.Lock()
+	defer namedLock.Unlock()
+	for id := range items {
+		if v, ok := namedStatements[id]; ok && v.source != source {
+			return fmt.Errorf("SQL模板重复: %s", id)
+		}
+	}
+	for id, v := range namedStatements {
+		if v.source == source {
+			delete(namedStatements, id)
+		}
+	}
+	for id, v := range items {
+		namedStatements[id] = v
+	}
+	return nil
+}
+
+func parseNamedXML(data []byte) (*namedNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root *namedNode
+	stack := make([]*namedNode, 0)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &namedNode{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, &namedNode{text: string(t)})
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("SQL模板为空")
+	}
+	return root, nil
+}
+
+// 按名称生成SQL（? 占位符）及参数，params 为结构体或 map
+func Named(name string, params interface{}) (string, []interface{}, error) {
+	namedLock.RLock()
+	stmt, ok := namedStatements[name]
+	namedLock.RUnlock()
+	if !ok {
+		return "", nil, errors.New("SQL模板不存在: " + name)
+	}
+	r := &namedRender{params: params, vars: make(map[string]interface{})}
+	if err := r.children(stmt, stmt.namespace, 0); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimSpace(r.sql.String()), r.values, nil
+}
+
+type namedRender struct {
+	params interface{}
+	// foreach 的当前元素及下标
+	vars   map[string]interface{}
+	sql    strings.Builder
+	values []interface{}
+}
+
+func (m *namedRender) children(node *namedNode, namespace string, depth int) error {
+	for _, child := range node.children {
+		if err := m.node(child, namespace, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *namedRender) node(node *namedNode, namespace string, depth int) error {
+	switch node.name {
+	case "":
+		return m.text(node.text)
+	case "if":
+		ok, err := m.test(node.attrs["test"])
+		if err != nil || !ok {
+			return err
+		}
+		return m.children(node, namespace, depth)
+	case "choose":
+		for _, child := range node.children {
+			switch child.name {
+			case "when":
+				ok, err := m.test(child.attrs["test"])
+				if err != nil {
+					return err
+				}
+				if ok {
+					return m.children(child, namespace, depth)
+				}
+			case "otherwise":
+				return m.children(child, namespace, depth)
+			}
+		}
+		return nil
+	case "where":
+		sub := &namedRender{params: m.params, vars: m.vars}
+		if err := sub.children(node, namespace, depth); err != nil {
+			return err
+		}
+		// 去掉开头的 and、or
+		sql := strings.TrimSpace(sub.sql.String())
+		lower := strings.ToLower(sql)
+		for _, prefix := range []string{"and", "or"} {
+			if strings.HasPrefix(lower, prefix) && len(sql) > len(prefix) && strings.ContainsRune(" \t\r\n(", rune(sql[len(prefix)])) {
+				sql = strings.TrimSpace(sql[len(prefix):])
+				break
+			}
+		}
+		if sql != "" {
+			m.sql.WriteString(" where " + sql + " ")
+			m.values = append(m.values, sub.values...)
+		}
+		return nil
+	case "foreach":
+		return m.foreach(node, namespace, depth)
+	case "include":
+		if depth > 10 {
+			return errors.New("include 嵌套过深")
+		}
+		id := node.attrs["refid"]
+		if !strings.Contains(id, ".") && namespace != "" {
+			id = namespace + "." + id
+		}
+		namedLock.RLock()
+		fragment, ok := namedStatements[id]
+		namedLock.RUnlock()
+		if !ok {
+			return errors.New("SQL片段不存在: " + id)
+		}
+		return m.children(fragment, fragment.namespace, depth+1)
+	}
+	return fmt.Errorf("不支持的节点: %s", node.name)
+}
+
+// <foreach collection="ids" item="id" index="i" open="(" separator="," close=")">
+func (m *namedRender) foreach(node *namedNode, namespace string, depth int) error {
+	collection := reflect.ValueOf(m.lookup(node.attrs["collection"]))
+	for collection.Kind() == reflect.Ptr || collection.Kind() == reflect.Interface {
+		collection = collection.Elem()
+	}
+	if collection.Kind() != reflect.Slice && collection.Kind() != reflect.Array || collection.Len() == 0 {
+		return nil
+	}
+	item, index := node.attrs["item"], node.attrs["index"]
+	saved := make(map[string]interface{})
+	for _, k := range []string{item, index} {
+		if v, ok := m.vars[k]; ok && k != "" {
+			saved[k] = v
+		}
+	}
+	m.sql.WriteString(node.attrs["open"])
+	for i := 0; i < collection.Len(); i++ {
+		if i > 0 {
+			m.sql.WriteString(node.attrs["separator"])
+		}
+		if item != "" {
+			m.vars[item] = collection.Index(i).Interface()
+		}
+		if index != "" {
+			m.vars[index] = i
+		}
+		if err := m.children(node, namespace, depth); err != nil {
+			return err
+		}
+	}
+	m.sql.WriteString(node.attrs["close"])
+	for _, k := range []string{item, index} {
+		delete(m.vars, k)
+		if v, ok := saved[k]; ok {
+			m.vars[k] = v
+		}
+	}
+	return nil
+}
+
+// 文本中的 #{参数} 替换为占位符，切片参数展开为多个占位符
+func (m *namedRender) text(s string) error {
+	for {
+		i := strings.Index(s, "#{")
+		if i < 0 {
+			m.sql.WriteString(s)
+			return nil
+		}
+		j := strings.Index(s[i:], "}")
+		if j < 0 {
+			return errors.New("参数缺少 }")
+		}
+		m.sql.WriteString(s[:i])
+		name := strings.TrimSpace(strings.Split(s[i+2:i+j], ",")[0])
+		value := m.lookup(name)
+		rv := reflect.ValueOf(namedValue(value))
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+			if rv.Len() == 0 {
+				m.sql.WriteString("null")
+			}
+			for k := 0; k < rv.Len(); k++ {
+				if k > 0 {
+					m.sql.WriteString(", ")
+				}
+				m.sql.WriteString("?")
+				m.values = append(m.values, rv.Index(k).Interface())
+			}
+		} else {
+			m.sql.WriteString("?")
+			m.values = append(m.values, value)
+		}
+		s = s[i+j+1:]
+	}
+}
+
+// 按路径取参数值：a.b.c，依次匹配 foreach 变量、map 键、结构体字段名/json名/db名；不存在时为nil
+func (m *namedRender) lookup(path string) interface{} {
+	parts := strings.Split(path, ".")
+	var current interface{}
+	if v, ok := m.vars[parts[0]]; ok {
+		current = v
+		parts = parts[1:]
+	} else {
+		current = m.params
+	}
+	for _, part := range parts {
+		current = namedField(current, part)
+		if current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+func namedField(obj interface{}, name string) interface{} {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !value.IsValid() {
+			return nil
+		}
+		return value.Interface()
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			tagJson := strings.Split(f.Tag.Get("json"), ",")[0]
+			tagDB := strings.Split(f.Tag.Get("db"), ",")[0]
+			if f.Name == name || tagJson == name || tagDB == name || strings.EqualFold(f.Name, name) {
+				return v.Field(i).Interface()
+			}
+		}
+		// 内嵌结构体
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).Anonymous {
+				if value := namedField(v.Field(i).Interface(), name); value != nil {
+					return value
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// 计算 test 表达式
+func (m *namedRender) test(expr string) (bool, error) {
+	p := &namedExpr{tokens: namedTokens(expr), render: m}
+	value, err := p.or()
+	if err != nil {
+		return false, fmt.Errorf("表达式错误 %q: %w", expr, err)
+	}
+	if p.pos < len(p.tokens) {
+		return false, fmt.Errorf("表达式错误 %q: %s", expr, p.tokens[p.pos])
+	}
+	return truthy(value), nil
+}
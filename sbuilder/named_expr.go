@@ -0,0 +1,274 @@
+package sbuilder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// test 表达式：参数路径、字符串（单/双引号）、数字、true/false/null，
+// 比较 == != > < >= <=（或 eq neq gt lt gte lte），逻辑 and or not（或 && || !），括号及 len(参数)
+type namedExpr struct {
+	tokens []string
+	pos    int
+	render *namedRender
+}
+
+func namedTokens(s string) []string {
+	tokens := make([]string, 0)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'' || c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				j++
+			}
+			tokens = append(tokens, s[i:min(j+1, len(s))])
+			i = j + 1
+		case strings.ContainsRune("=!<>&|", rune(c)):
+			if i+1 < len(s) && namedTwoChar[s[i:i+2]] {
+				tokens = append(tokens, s[i:i+2])
+				i += 2
+			} else {
+				tokens = append(tokens, s[i:i+1])
+				i++
+			}
+		case c == '(' || c == ')':
+			tokens = append(tokens, s[i:i+1])
+			i++
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n\r'\"=!<>&|()", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+func (m *namedExpr) peek() string {
+	if m.pos < len(m.tokens) {
+		return m.tokens[m.pos]
+	}
+	return ""
+}
+
+func (m *namedExpr) next() string {
+	t := m.peek()
+	m.pos++
+	return t
+}
+
+func (m *namedExpr) or() (interface{}, error) {
+	left, err := m.and()
+	if err != nil {
+		return nil, err
+	}
+	for t := m.peek(); t == "or" || t == "||"; t = m.peek() {
+		m.next()
+		right, err := m.and()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) || truthy(right)
+	}
+	return left, nil
+}
+
+func (m *namedExpr) and() (interface{}, error) {
+	left, err := m.not()
+	if err != nil {
+		return nil, err
+	}
+	for t := m.peek(); t == "and" || t == "&&"; t = m.peek() {
+		m.next()
+		right, err := m.not()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) && truthy(right)
+	}
+	return left, nil
+}
+
+func (m *namedExpr) not() (interface{}, error) {
+	if t := m.peek(); t == "not" || t == "!" {
+		m.next()
+		v, err := m.not()
+		if err != nil {
+			return nil, err
+		}
+		return !truthy(v), nil
+	}
+	return m.compare()
+}
+
+var namedTwoChar = map[string]bool{"==": true, "!=": true, "<=": true, ">=": true, "&&": true, "||": true}
+
+var namedOperators = map[string]string{"eq": "==", "neq": "!=", "gt": ">", "lt": "<", "gte": ">=", "lte": "<="}
+
+func (m *namedExpr) compare() (interface{}, error) {
+	left, err := m.primary()
+	if err != nil {
+		return nil, err
+	}
+	op := m.peek()
+	if v, ok := namedOperators[op]; ok {
+		op = v
+	}
+	switch op {
+	case "==", "!=", ">", "<", ">=", "<=":
+		m.next()
+		right, err := m.primary()
+		if err != nil {
+			return nil, err
+		}
+		return compareValues(left, right, op)
+	}
+	return left, nil
+}
+
+func (m *namedExpr) primary() (interface{}, error) {
+	t := m.next()
+	switch {
+	case t == "":
+		return nil, errors.New("表达式不完整")
+	case t == "(":
+		v, err := m.or()
+		if err != nil {
+			return nil, err
+		}
+		if m.next() != ")" {
+			return nil, errors.New("缺少 )")
+		}
+		return v, nil
+	case t == "null" || t == "nil":
+		return nil, nil
+	case t == "true" || t == "false":
+		return t == "true", nil
+	case t[0] == '\'' || t[0] == '"':
+		return strings.Trim(t, t[:1]), nil
+	case t == "len" && m.peek() == "(":
+		m.next()
+		v, err := m.or()
+		if err != nil {
+			return nil, err
+		}
+		if m.next() != ")" {
+			return nil, errors.New("缺少 )")
+		}
+		rv := reflect.ValueOf(namedValue(v))
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+			return rv.Len(), nil
+		}
+		return 0, nil
+	}
+	if n, err := strconv.ParseFloat(t, 64); err == nil {
+		return n, nil
+	}
+	return m.render.lookup(t), nil
+}
+
+// 解引用指针，nil 指针、切片、map 为nil
+func namedValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map) && rv.IsNil() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func compareValues(left, right interface{}, op string) (bool, error) {
+	left, right = namedValue(left), namedValue(right)
+	if left == nil || right == nil {
+		switch op {
+		case "==":
+			return left == nil && right == nil, nil
+		case "!=":
+			return !(left == nil && right == nil), nil
+		}
+		return false, nil
+	}
+	var c int
+	l, lok := toFloat(left)
+	r, rok := toFloat(right)
+	switch {
+	case lok && rok:
+		c = compareOrdered(l, r)
+	default:
+		ls, rs := fmt.Sprint(left), fmt.Sprint(right)
+		if _, ok := left.(string); !ok && op != "==" && op != "!=" {
+			return false, fmt.Errorf("无法比较: %v %s %v", left, op, right)
+		}
+		c = strings.Compare(ls, rs)
+	}
+	switch op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case ">":
+		return c > 0, nil
+	case "<":
+		return c < 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return c <= 0, nil
+}
+
+func compareOrdered(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+// 值的真假：nil、false、0、空字符串、空集合为假
+func truthy(v interface{}) bool {
+	v = namedValue(v)
+	if v == nil {
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	}
+	if n, ok := toFloat(v); ok {
+		return n != 0
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package sbuilder
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const namedDemo = `<mapper namespace="demo">
+	<sql id="columns">id, name</sql>
+	<select id="find">
+		select <include refid="columns"/> from sys_user
+		<where>
+			<if test="name != null and name != ''">and name like #{name}</if>
+			<if test="len(ids) > 0">and id in <foreach collection="ids" item="id" open="(" separator="," close=")">#{id}</foreach></if>
+			<choose>
+				<when test="state == 1">and state = 1</when>
+				<otherwise>and state &lt;&gt; 9</otherwise>
+			</choose>
+		</where>
+	</select>
+</mapper>`
+
+type namedParams struct {
+	Name  string   `json:"name"`
+	Ids   []string `json:"ids"`
+	State *int     `json:"state"`
+}
+
+func TestNamed(t *testing.T) {
+	if err := ParseNamed([]byte(namedDemo)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ParseNamed([]byte(namedDemo)); err != nil {
+		t.Fatal("重复解析相同内容报错", err)
+	}
+	if err := ParseNamed([]byte(strings.Replace(namedDemo, "sys_user", "sys_role", 1))); err == nil {
+		t.Fatal("重复的SQL模板未报错")
+	}
+	sql, values, err := Named("demo.find", map[string]interface{}{"name": "a%", "ids": []string{"1", "2"}, "state": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql = strings.Join(strings.Fields(sql), " ")
+	if sql != "select id, name from sys_user where name like ? and id in (?,?) and state = 1" || len(values) != 3 {
+		t.Fatal(sql, values)
+	}
+	sql, values, err = Named("demo.find", &namedParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql = strings.Join(strings.Fields(sql), " ")
+	if sql != "select id, name from sys_user where state <> 9" || len(values) != 0 {
+		t.Fatal(sql, values)
+	}
+	if _, _, err = Named("demo.none", nil); err == nil {
+		t.Fatal("不存在的SQL模板未报错")
+	}
+}
+
+func TestLoadNamed(t *testing.T) {
+	fsys := fstest.MapFS{"sql/a.xml": {Data: []byte(`<mapper namespace="load"><select id="a">select 1</select><select id="b">select 2</select></mapper>`)}}
+	if err := LoadNamed(fsys, "sql/*.xml"); err != nil {
+		t.Fatal(err)
+	}
+	fsys["sql/a.xml"] = &fstest.MapFile{Data: []byte(`<mapper namespace="load"><select id="a">select 3</select></mapper>`)}
+	if err := LoadNamed(fsys, "sql/*.xml"); err != nil {
+		t.Fatal("重新加载报错", err)
+	}
+	if sql, _, err := Named("load.a", nil); err != nil || strings.TrimSpace(sql) != "select 3" {
+		t.Fatal(sql, err)
+	}
+	if _, _, err := Named("load.b", nil); err == nil {
+		t.Fatal("已移除的SQL模板仍可使用")
+	}
+	fsys["sql/b.xml"] = &fstest.MapFile{Data: []byte(`<mapper namespace="load"><select id="a">select 4</select></mapper>`)}
+	if err := LoadNamed(fsys, "sql/*.xml"); err == nil {
+		t.Fatal("不同文件的SQL模板重复未报错")
+	}
+}
@@ -0,0 +1,146 @@
+package sbuilder
+
+import (
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/androidsr/sc-go/model"
+	"github.com/androidsr/sc-go/sc"
+)
+
+// 排序列白名单：前端传入的列名（json名、字段名或列名） -> 数据库列名
+type OrderColumns map[string]string
+
+// 按结构体tag生成排序列白名单，obj 可为结构体、结构体指针或切片
+func OrderColumnsOf(obj interface{}) OrderColumns {
+	result := make(OrderColumns)
+	if obj == nil {
+		return result
+	}
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return result
+	}
+	for _, f := range getStructMeta(t).fields {
+		result[f.TagDB] = f.TagDB
+		result[f.Name] = f.TagDB
+		if f.json != "" {
+			result[f.json] = f.TagDB
+		}
+	}
+	return result
+}
+
+// 增加允许排序的数据库列
+func (m OrderColumns) Allow(columns ...string) OrderColumns {
+	for _, v := range columns {
+		m[v] = v
+	}
+	return m
+}
+
+// 校验前端传入的列名，返回对应的数据库列名
+func (m OrderColumns) Column(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	v, ok := m[sc.GetUnderscore(name)]
+	return v, ok
+}
+
+// 解析排序项：只保留白名单内的列，列名转换为数据库列名
+func ResolveOrders(orders []model.OrderItem, allowed OrderColumns) []model.OrderItem {
+	result := make([]model.OrderItem, 0, len(orders))
+	for _, v := range orders {
+		column, ok := allowed.Column(v.Column)
+		if !ok {
+			log.Printf("忽略不允许的排序列:%s", v.Column)
+			continue
+		}
+		result = append(result, model.OrderItem{Column: column, Asc: v.Asc})
+	}
+	return result
+}
+
+// 生成 order by 子句：只使用白名单内的列，其余列忽略；没有有效排序列时返回空字符串
+func BuildOrderBy(d Dialect, orders []model.OrderItem, allowed OrderColumns) string {
+	return OrderByClause(d, ResolveOrders(orders, allowed))
+}
+
+// 按已校验的排序项生成 order by 子句
+func OrderByClause(d Dialect, orders []model.OrderItem) string {
+	if len(orders) == 0 {
+		return ""
+	}
+	items := make([]string, 0, len(orders))
+	for _, v := range orders {
+		if v.Asc {
+			items = append(items, d.Quote(v.Column)+" asc")
+		} else {
+			items = append(items, d.Quote(v.Column)+" desc")
+		}
+	}
+	return "order by " + strings.Join(items, ", ")
+}
+
+// 游标分页条件：取排序在 values 之后的数据，orders 为已校验的排序项
+// 排序方向一致且数据库支持行值比较时生成 (a, b) > (?, ?)，否则展开为 a > ? or (a = ? and b > ?)
+func Keyset(orders []model.OrderItem, values []interface{}) Condition {
+	return &keyset{orders, values}
+}
+
+type keyset struct {
+	orders []model.OrderItem
+	values []interface{}
+}
+
+func (m *keyset) Build(d Dialect) (string, []interface{}) {
+	if len(m.orders) == 0 || len(m.orders) != len(m.values) {
+		return "", nil
+	}
+	if d == nil {
+		d = DefaultDialect()
+	}
+	columns := make([]string, len(m.orders))
+	ops := make([]string, len(m.orders))
+	same := true
+	for i, v := range m.orders {
+		columns[i] = d.Quote(v.Column)
+		ops[i] = " < "
+		if v.Asc {
+			ops[i] = " > "
+		}
+		same = same && v.Asc == m.orders[0].Asc
+	}
+	switch d.Name() {
+	case MySQL, Postgres, SQLite:
+		if same && len(columns) > 1 {
+			return "(" + strings.Join(columns, ", ") + ")" + ops[0] + "(" + Placeholders(len(columns)) + ")", m.values
+		}
+	}
+	parts := make([]string, 0, len(columns))
+	values := make([]interface{}, 0)
+	for i := range columns {
+		items := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			items = append(items, columns[j]+" = ?")
+			values = append(values, m.values[j])
+		}
+		items = append(items, columns[i]+ops[i]+"?")
+		values = append(values, m.values[i])
+		if len(items) == 1 {
+			parts = append(parts, items[0])
+		} else {
+			parts = append(parts, "("+strings.Join(items, " and ")+")")
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0], values
+	}
+	return "(" + strings.Join(parts, " or ") + ")", values
+}
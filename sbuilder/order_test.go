@@ -0,0 +1,39 @@
+package sbuilder
+
+import (
+	"testing"
+
+	"github.com/androidsr/sc-go/model"
+)
+
+func TestBuildOrderBy(t *testing.T) {
+	allowed := OrderColumnsOf(&[]SysUser{}).Allow("create_time")
+	orders := []model.OrderItem{
+		{Column: "userName", Asc: true},
+		{Column: "id;drop table sys_user", Asc: true},
+		{Column: "Email"},
+		{Column: "createTime"},
+		{Column: "(select 1)"},
+	}
+	got := BuildOrderBy(GetDialect(MySQL), orders, allowed)
+	want := "order by `user_name` asc, `mail` desc, `create_time` desc"
+	if got != want {
+		t.Errorf("order\n%s\n%s", got, want)
+	}
+	if got := BuildOrderBy(GetDialect(MySQL), orders[1:2], allowed); got != "" {
+		t.Errorf("rejected %s", got)
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	orders := []model.OrderItem{{Column: "create_time"}, {Column: "id"}}
+	sql, values := Keyset(orders, []interface{}{"t", "1"}).Build(GetDialect(Postgres))
+	if sql != `("create_time", "id") < (?, ?)` || len(values) != 2 {
+		t.Errorf("tuple %s %v", sql, values)
+	}
+	orders[1].Asc = true
+	sql, values = Keyset(orders, []interface{}{"t", "1"}).Build(GetDialect(Postgres))
+	if sql != `("create_time" < ? or ("create_time" = ? and "id" > ?))` || len(values) != 3 {
+		t.Errorf("expand %s %v", sql, values)
+	}
+}
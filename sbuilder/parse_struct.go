@@ -1,12 +1,16 @@
 package sbuilder
 
 import (
+	"database/sql/driver"
+	"errors"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/androidsr/sc-go/sc"
-
-	"github.com/oleiade/reflections"
 )
 
 const (
@@ -17,6 +21,9 @@ const (
 var (
 	insertFill = make(map[string]func() any, 0)
 	updateFill = make(map[string]func() any, 0)
+	// 逻辑删除默认值：已删除、未删除
+	logicDeleted interface{} = 1
+	logicActive  interface{} = 0
 )
 
 type OrmAction int
@@ -32,10 +39,50 @@ func AddUpdateFill(column string, call func() any) {
 	updateFill[column] = call
 }
 
+// 设置逻辑删除默认值（字段未通过 logic tag 指定时使用），为空时保持原值
+func SetLogicDelete(deleted, active string) {
+	if deleted != "" {
+		logicDeleted = logicValue(deleted)
+	}
+	if active != "" {
+		logicActive = logicValue(active)
+	}
+}
+
+// 数字字符串按数字处理，其余按字符串处理
+func logicValue(s string) interface{} {
+	s = strings.TrimSpace(s)
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v
+	}
+	return s
+}
+
 type StructInfo struct {
 	TableName  string
 	PrimaryKey string
-	Fields     []FieldInfo
+	// 乐观锁版本号列
+	Version string
+	// 逻辑删除列及查询列（可带表别名）
+	LogicDelete string
+	LogicColumn string
+	// 逻辑删除已删除值、未删除值
+	DeletedValue interface{}
+	ActiveValue  interface{}
+	// 为true时查询不追加未删除条件
+	Unscoped bool
+	// 租户列及当前租户ID，不处理租户时列为空
+	TenantColumn string
+	TenantValue  interface{}
+	// 为true时不处理租户
+	IgnoreTenant bool
+	// 数据权限规则，为nil时不限制
+	Scope *ScopeRule
+	// 为true时不处理数据权限
+	IgnoreScope bool
+	// 解析字段值时的错误，如加密失败
+	Err    error
+	Fields []FieldInfo
 }
 
 func (m *StructInfo) GetDbValues(action OrmAction) ([]string, []interface{}) {
@@ -52,6 +99,16 @@ func (m *StructInfo) GetDbValues(action OrmAction) ([]string, []interface{}) {
 	return columns, values
 }
 
+// 是否包含指定列的查询值
+func (m *StructInfo) hasField(column string) bool {
+	for _, v := range m.Fields {
+		if v.TagDB == column {
+			return true
+		}
+	}
+	return false
+}
+
 type FieldInfo struct {
 	Name       string
 	TagDB      string
@@ -60,160 +117,416 @@ type FieldInfo struct {
 	Value      interface{}
 }
 
-func GetField(obj interface{}, fillType int) *StructInfo {
-	result := new(StructInfo)
-	result.Fields = make([]FieldInfo, 0)
-	result.TableName = sc.GetUnderscore(reflect.Indirect(reflect.ValueOf(obj)).Type().Name())
-
-	fields, _ := reflections.Fields(obj)
-	var item FieldInfo
-	for _, fName := range fields {
-		kind, _ := reflections.GetFieldKind(obj, fName)
-		tagDB, _ := reflections.GetFieldTag(obj, fName, "db")
-		tagKeyword, _ := reflections.GetFieldTag(obj, fName, "keyword")
-		tagColumn, _ := reflections.GetFieldTag(obj, fName, "column")
-		tagJson, _ := reflections.GetFieldTag(obj, fName, "json")
-		if kind == reflect.Struct {
-			value, _ := reflections.GetField(obj, fName)
-			if tagDB == "-" {
-				continue
-			}
-			if tagColumn == "-" {
-				continue
+// 结构体元数据（按类型缓存，只在首次使用时解析tag）
+type structMeta struct {
+	tableName  string
+	primaryKey string
+	version    string
+	// 逻辑删除字段，为nil时未启用
+	logic *logicMeta
+	// 数据权限本人、部门字段
+	scopeUser *FieldInfo
+	scopeDept *FieldInfo
+	// 是否有加密字段
+	encrypted bool
+	fields    []fieldMeta
+}
+
+// 逻辑删除字段元数据，deleted/active 为nil时使用默认值
+type logicMeta struct {
+	column    string
+	tagColumn string
+	deleted   interface{}
+	active    interface{}
+}
+
+type fieldMeta struct {
+	FieldInfo
+	index []int
+	// json 名称
+	json string
+	// 加密字段，为nil时不加密
+	encrypt *encryptMeta
+}
+
+var (
+	metaCache  sync.Map
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	timeType   = reflect.TypeOf(time.Time{})
+)
+
+// 作为单列值处理的结构体类型：sql.Null*、time.Time 等
+func isValueType(t reflect.Type) bool {
+	return t == timeType || t.Implements(valuerType) || reflect.PointerTo(t).Implements(valuerType)
+}
+
+// 字段值是否为空：nil、空字符串、nil指针、无效的 sql.Null*、零值时间
+func isEmpty(fv reflect.Value, value interface{}) bool {
+	if value == nil || value == "" {
+		return true
+	}
+	if fv.Kind() == reflect.Ptr && fv.IsNil() {
+		return true
+	}
+	if t, ok := value.(time.Time); ok {
+		return t.IsZero()
+	}
+	if v, ok := value.(driver.Valuer); ok {
+		if fv.Kind() == reflect.Ptr {
+			return false
+		}
+		dv, err := v.Value()
+		return err == nil && dv == nil
+	}
+	return false
+}
+
+// 获取结构体元数据
+func getStructMeta(t reflect.Type) *structMeta {
+	if v, ok := metaCache.Load(t); ok {
+		return v.(*structMeta)
+	}
+	meta := parseStructMeta(t)
+	v, _ := metaCache.LoadOrStore(t, meta)
+	return v.(*structMeta)
+}
+
+func parseStructMeta(t reflect.Type) *structMeta {
+	meta := &structMeta{tableName: sc.GetUnderscore(t.Name()), fields: make([]fieldMeta, 0, t.NumField())}
+	explicitKey := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tagDB := field.Tag.Get("db")
+		tagColumn := field.Tag.Get("column")
+		if tagDB == "-" || tagColumn == "-" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && !isValueType(field.Type) {
+			sub := getStructMeta(field.Type)
+			for _, f := range sub.fields {
+				f.index = append([]int{i}, f.index...)
+				meta.fields = append(meta.fields, f)
 			}
-			pResult := GetField(value, fillType)
-			result.Fields = append(result.Fields, pResult.Fields...)
-		} else {
-			item = FieldInfo{}
-			item.Name = fName
-			item.TagDB = tagDB
-			item.TagKeyword = tagKeyword
-			item.TagColumn = tagColumn
-			if item.TagDB == "-" {
-				continue
+			if meta.primaryKey == "" {
+				meta.primaryKey = sub.primaryKey
 			}
-			if item.TagColumn == "-" {
-				continue
+			if meta.version == "" {
+				meta.version = sub.version
 			}
-			if item.TagDB == "" {
-				if strings.Contains(tagJson, ",") {
-					tagJson = strings.Split(tagJson, ",")[0]
-				}
-				item.TagDB = sc.GetUnderscore(tagJson)
+			if meta.logic == nil {
+				meta.logic = sub.logic
 			}
-			if item.TagDB == "" {
-				item.TagDB = sc.GetUnderscore(fName)
+			if meta.scopeUser == nil {
+				meta.scopeUser = sub.scopeUser
 			}
-			if strings.Contains(item.TagDB, ",") || strings.Contains(item.TagDB, " ") {
-				var ks []string
-				if strings.Contains(item.TagDB, ",") {
-					ks = strings.Split(item.TagDB, ",")
-				} else if strings.Contains(item.TagDB, " ") {
-					ks = strings.Split(item.TagDB, " ")
-				}
-				item.TagDB = ks[0]
-				pk := ks[1]
-				if pk == "primary_key" || pk == "primaryKey" || pk == "pk" {
-					result.PrimaryKey = item.TagDB
-				}
+			if meta.scopeDept == nil {
+				meta.scopeDept = sub.scopeDept
 			}
-			if result.PrimaryKey == "" && strings.ToLower(item.TagDB) == "id" {
-				result.PrimaryKey = item.TagDB
+			meta.encrypted = meta.encrypted || sub.encrypted
+			continue
+		}
+		item := FieldInfo{}
+		item.Name = field.Name
+		item.TagDB = tagDB
+		item.TagKeyword = field.Tag.Get("keyword")
+		item.TagColumn = tagColumn
+		tagJson := field.Tag.Get("json")
+		if strings.Contains(tagJson, ",") {
+			tagJson = strings.Split(tagJson, ",")[0]
+		}
+		if tagJson == "-" {
+			tagJson = ""
+		}
+		if item.TagDB == "" {
+			item.TagDB = sc.GetUnderscore(tagJson)
+		}
+		if item.TagDB == "" {
+			item.TagDB = sc.GetUnderscore(field.Name)
+		}
+		if strings.Contains(item.TagDB, ",") || strings.Contains(item.TagDB, " ") {
+			var ks []string
+			if strings.Contains(item.TagDB, ",") {
+				ks = strings.Split(item.TagDB, ",")
+			} else {
+				ks = strings.Split(item.TagDB, " ")
 			}
-			if item.TagColumn == "" {
-				item.TagColumn = item.TagDB
+			item.TagDB = ks[0]
+			for _, opt := range ks[1:] {
+				switch strings.TrimSpace(opt) {
+				case "primary_key", "primaryKey", "pk":
+					meta.primaryKey = item.TagDB
+					explicitKey = true
+				case "version":
+					meta.version = item.TagDB
+				case "logic_delete", "logicDelete":
+					meta.logic = &logicMeta{column: item.TagDB}
+				}
 			}
-			if item.TagKeyword == "" {
-				item.TagKeyword = "eq"
+		}
+		if !explicitKey && meta.primaryKey == "" && strings.ToLower(item.TagDB) == "id" {
+			meta.primaryKey = item.TagDB
+		}
+		if item.TagColumn == "" {
+			item.TagColumn = item.TagDB
+		}
+		if meta.logic != nil && meta.logic.column == item.TagDB && meta.logic.tagColumn == "" {
+			meta.logic.tagColumn = item.TagColumn
+			// logic:"已删除值,未删除值"
+			if ks := strings.Split(field.Tag.Get("logic"), ","); len(ks) == 2 {
+				meta.logic.deleted = logicValue(ks[0])
+				meta.logic.active = logicValue(ks[1])
 			}
+		}
+		if item.TagKeyword == "" {
+			item.TagKeyword = "eq"
+		}
+		encrypt := parseEncrypt(field.Tag.Get("encrypt"))
+		meta.encrypted = meta.encrypted || encrypt != nil
+		meta.fields = append(meta.fields, fieldMeta{FieldInfo: item, index: []int{i}, json: tagJson, encrypt: encrypt})
+		// scope:"user" 本人数据列，scope:"dept" 部门数据列
+		switch field.Tag.Get("scope") {
+		case "user":
+			meta.scopeUser = &item
+		case "dept":
+			meta.scopeDept = &item
+		}
+	}
+	return meta
+}
 
-			value, _ := reflections.GetField(obj, fName)
-			if value == nil || value == "" {
-				var autoFunc func() any
-				if fillType == 1 {
-					autoFunc = insertFill[item.TagDB]
-				} else if fillType == 2 {
-					autoFunc = updateFill[item.TagDB]
-				} else {
-					continue
-				}
-				if autoFunc == nil {
-					continue
-				}
-				val := autoFunc()
-				if val == nil || val == "" {
-					continue
-				}
-				value = val
-				reflections.SetField(obj, fName, value)
+// 结构体表信息（不含字段）
+func (m *structMeta) structInfo() *StructInfo {
+	result := &StructInfo{TableName: m.tableName, PrimaryKey: m.primaryKey, Version: m.version}
+	if m.logic != nil {
+		result.LogicDelete = m.logic.column
+		result.LogicColumn = m.logic.tagColumn
+		result.DeletedValue, result.ActiveValue = logicDeleted, logicActive
+		if m.logic.deleted != nil {
+			result.DeletedValue, result.ActiveValue = m.logic.deleted, m.logic.active
+		}
+	}
+	return result
+}
+
+// 解析结构体字段及值，fillType 1:插入填充 2:更新填充 0:不填充
+func GetField(obj interface{}, fillType int) *StructInfo {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return &StructInfo{Fields: make([]FieldInfo, 0)}
+	}
+	meta := getStructMeta(v.Type())
+	result := meta.structInfo()
+	result.Fields = make([]FieldInfo, 0, len(meta.fields))
+	for _, f := range meta.fields {
+		fv := v.FieldByIndex(f.index)
+		value := fv.Interface()
+		if isEmpty(fv, value) {
+			var autoFunc func() any
+			if fillType == 1 {
+				autoFunc = insertFill[f.TagDB]
+			} else if fillType == 2 {
+				autoFunc = updateFill[f.TagDB]
+			} else {
+				continue
 			}
-			if value == nil || value == "" || value == -99 {
+			if autoFunc == nil {
 				continue
 			}
-			switch value.(type) {
-			case string:
-				if value == "" {
-					continue
-				}
+			val := autoFunc()
+			if val == nil || val == "" {
+				continue
 			}
-			if value == "-" {
-				value = ""
+			value = val
+			if rv := reflect.ValueOf(val); fv.CanSet() && rv.Type().AssignableTo(fv.Type()) {
+				fv.Set(rv)
 			}
-			item.Value = value
-			result.Fields = append(result.Fields, item)
 		}
+		if isEmpty(reflect.ValueOf(value), value) || value == -99 {
+			continue
+		}
+		if value == "-" {
+			value = ""
+		}
+		item := f.FieldInfo
+		item.Value = value
+		if f.encrypt != nil {
+			encryptField(result, &item, f.encrypt, fillType == 0)
+		}
+		result.Fields = append(result.Fields, item)
 	}
+	result.TableName = tableName(v, meta)
+	result.TenantColumn, result.TenantValue = Tenant(result.TableName)
+	result.Scope = scopeRule(v, meta, false)
+	registerEncryptTable(result.TableName, meta)
 	return result
 }
 
+// 解析结构体指定列（数据库列名或字段名）及主键、版本号列，按字段原值返回，不跳过零值及空值；
+// 未指定的列中注册了更新填充（AddUpdateFill）的列按填充值一并更新
+func GetFieldColumns(obj interface{}, columns ...string) (*StructInfo, error) {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, errors.New("对象不是结构体")
+	}
+	meta := getStructMeta(v.Type())
+	result := meta.structInfo()
+	result.TableName = tableName(v, meta)
+	result.TenantColumn, result.TenantValue = Tenant(result.TableName)
+	result.Fields = make([]FieldInfo, 0, len(columns)+2)
+	for _, f := range meta.fields {
+		if f.TagDB != meta.primaryKey && f.TagDB != meta.version {
+			continue
+		}
+		item := f.FieldInfo
+		item.Value = v.FieldByIndex(f.index).Interface()
+		result.Fields = append(result.Fields, item)
+	}
+	for _, column := range columns {
+		found := false
+		for _, f := range meta.fields {
+			if f.TagDB != column && f.Name != column {
+				continue
+			}
+			found = true
+			if f.TagDB != meta.primaryKey && f.TagDB != meta.version {
+				item := f.FieldInfo
+				item.Value = v.FieldByIndex(f.index).Interface()
+				if f.encrypt != nil {
+					encryptField(result, &item, f.encrypt, false)
+				}
+				result.Fields = append(result.Fields, item)
+			}
+			break
+		}
+		if !found {
+			return nil, errors.New("列不存在: " + column)
+		}
+	}
+	for _, f := range meta.fields {
+		autoFunc := updateFill[f.TagDB]
+		if autoFunc == nil || f.TagDB == meta.primaryKey || f.TagDB == meta.version || slices.ContainsFunc(result.Fields, func(v FieldInfo) bool { return v.TagDB == f.TagDB }) {
+			continue
+		}
+		val := autoFunc()
+		if val == nil || val == "" {
+			continue
+		}
+		fv := v.FieldByIndex(f.index)
+		if rv := reflect.ValueOf(val); fv.CanSet() && rv.Type().AssignableTo(fv.Type()) {
+			fv.Set(rv)
+		}
+		item := f.FieldInfo
+		item.Value = val
+		if f.encrypt != nil {
+			encryptField(result, &item, f.encrypt, false)
+		}
+		result.Fields = append(result.Fields, item)
+	}
+	return result, nil
+}
+
+// 按数据库列名获取结构体字段，obj 需为指针才可设置字段值
+func FieldByColumn(obj interface{}, column string) (reflect.Value, bool) {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	for _, f := range getStructMeta(v.Type()).fields {
+		if f.TagDB == column {
+			return v.FieldByIndex(f.index), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
 type BetweenInfo struct {
 	Left  interface{} `json:"start"`
 	Right interface{} `json:"end"`
 }
 
 func BuildQuery(info *StructInfo) *SelectBuilder {
-	builder := Builder("")
+	return BuildQueryDialect(info, DefaultDialect())
+}
+
+// 按指定方言生成查询条件，多数据源时使用各自的方言
+func BuildQueryDialect(info *StructInfo, d Dialect) *SelectBuilder {
+	builder := Builder("").WithDialect(d)
+	tenant := info.TenantColumn != "" && !info.IgnoreTenant
 	for _, item := range info.Fields {
+		// 租户条件只使用当前租户
+		if tenant && item.TagDB == info.TenantColumn {
+			continue
+		}
 		keyword := item.TagKeyword
+		column := builder.dialect.Quote(item.TagColumn)
 		switch keyword {
 		case Eq:
-			builder.Eq(item.TagColumn, item.Value)
+			builder.Eq(column, item.Value)
 		case Ne:
-			builder.Ne(item.TagColumn, item.Value)
+			builder.Ne(column, item.Value)
 		case In:
-			builder.In(item.TagColumn, item.Value)
+			builder.In(column, item.Value)
 		case NotIn:
-			builder.NotIn(item.TagColumn, item.Value)
+			builder.NotIn(column, item.Value)
 		case Gt:
-			builder.Gt(item.TagColumn, item.Value)
+			builder.Gt(column, item.Value)
 		case Lt:
-			builder.Lt(item.TagColumn, item.Value)
+			builder.Lt(column, item.Value)
 		case Ge:
-			builder.Ge(item.TagColumn, item.Value)
+			builder.Ge(column, item.Value)
 		case Le:
-			builder.Le(item.TagColumn, item.Value)
+			builder.Le(column, item.Value)
 		case Between:
 			value, ok := item.Value.(BetweenInfo)
 			if !ok {
 				break
 			}
-			builder.Between(item.TagColumn, value)
+			builder.Between(column, value)
 		case NotBetween:
 			value, ok := item.Value.(BetweenInfo)
 			if !ok {
 				break
 			}
-			builder.NotBetween(item.TagColumn, value)
+			builder.NotBetween(column, value)
 		case Like:
-			builder.Like(item.TagColumn, item.Value)
+			builder.Like(column, item.Value)
 		case NotLike:
-			builder.NotLike(item.TagColumn, item.Value)
+			builder.NotLike(column, item.Value)
 		case LikeLeft:
-			builder.LikeLeft(item.TagColumn, item.Value)
+			builder.LikeLeft(column, item.Value)
 		case LikeRight:
-			builder.LikeRight(item.TagColumn, item.Value)
+			builder.LikeRight(column, item.Value)
 		}
 	}
+	if info.LogicDelete != "" && !info.Unscoped && !info.hasField(info.LogicDelete) {
+		builder.Eq(builder.dialect.Quote(info.LogicColumn), info.ActiveValue)
+	}
+	if tenant {
+		if info.TenantValue == nil {
+			builder.Where(Expr("1 = 0"))
+		} else {
+			builder.Eq(builder.dialect.Quote(info.TenantColumn), info.TenantValue)
+		}
+	}
+	if info.Scope != nil && !info.IgnoreScope {
+		builder.Where(info.Scope)
+	}
+	// 加密查询值失败时条件恒为假
+	if info.Err != nil {
+		builder.Where(Expr("1 = 0"))
+	}
 	return builder
 }
 
@@ -0,0 +1,128 @@
+package sbuilder
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+type BaseEntity struct {
+	CreateBy string `json:"createBy"`
+}
+
+type SysUser struct {
+	Id       string   `json:"id" db:"id,primary_key"`
+	UserName string   `json:"userName" keyword:"like"`
+	Email    string   `db:"mail" column:"u.mail"`
+	Roles    []string `json:"roles" keyword:"in"`
+	Password string   `db:"-"`
+	Age      int      `json:"age"`
+	Remark   string   `json:"-"`
+	BaseEntity
+}
+
+func TestGetField(t *testing.T) {
+	AddInsertFill("create_by", func() any { return "admin" })
+	defer delete(insertFill, "create_by")
+
+	user := &SysUser{Id: "1", UserName: "a", Email: "-", Age: -99, Password: "x", Remark: "r"}
+	info := GetField(user, 1)
+	if info.TableName != "sys_user" || info.PrimaryKey != "id" {
+		t.Fatalf("table %s, pk %s", info.TableName, info.PrimaryKey)
+	}
+	got := make(map[string]FieldInfo)
+	for _, f := range info.Fields {
+		got[f.TagDB] = f
+	}
+	if len(got) != 6 {
+		t.Fatalf("fields %v", info.Fields)
+	}
+	if got["user_name"].TagKeyword != "like" || got["id"].TagKeyword != "eq" {
+		t.Errorf("keyword %v", got)
+	}
+	if got["mail"].TagColumn != "u.mail" || got["mail"].Value != "" {
+		t.Errorf("mail %v", got["mail"])
+	}
+	if got["remark"].Value != "r" {
+		t.Errorf("remark %v", got["remark"])
+	}
+	if got["create_by"].Value != "admin" || user.CreateBy != "admin" {
+		t.Errorf("insert fill %v %s", got["create_by"], user.CreateBy)
+	}
+	if _, ok := got["age"]; ok {
+		t.Errorf("age should be skipped")
+	}
+}
+
+func BenchmarkGetField(b *testing.B) {
+	user := &SysUser{Id: "1", UserName: "a", Email: "b", Roles: []string{"r1", "r2"}, Age: 18}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetField(user, 0)
+	}
+}
+
+func BenchmarkBuildQuery(b *testing.B) {
+	user := &SysUser{Id: "1", UserName: "a", Email: "b", Roles: []string{"r1", "r2"}, Age: 18}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		BuildQuery(GetField(user, 0))
+	}
+}
+
+type SysNote struct {
+	Id    string `db:"id"`
+	State string `db:"state,logic_delete" column:"n.state" logic:"D,A"`
+}
+
+func TestLogicDelete(t *testing.T) {
+	info := GetField(&SysNote{Id: "1"}, 0)
+	if info.LogicDelete != "state" || info.DeletedValue != "D" || info.ActiveValue != "A" {
+		t.Fatalf("logic %s %v %v", info.LogicDelete, info.DeletedValue, info.ActiveValue)
+	}
+	b := BuildQuery(info)
+	if got := b.Sql.String(); !strings.Contains(got, "`n`.`state` = ?") || len(b.Values) != 2 || b.Values[1] != "A" {
+		t.Errorf("query %s %v", got, b.Values)
+	}
+	info.Unscoped = true
+	if b := BuildQuery(info); len(b.Values) != 1 {
+		t.Errorf("unscoped %v", b.Values)
+	}
+}
+
+type SysItem struct {
+	Id      string         `db:"id"`
+	Age     *int           `db:"age"`
+	Remark  sql.NullString `db:"remark"`
+	Created time.Time      `db:"created"`
+	Sort    int            `db:"sort"`
+}
+
+func TestGetFieldColumns(t *testing.T) {
+	age := 0
+	item := &SysItem{Id: "1", Age: &age, Sort: -99}
+	info := GetField(item, 0)
+	if len(info.Fields) != 2 || info.Fields[1].TagDB != "age" {
+		t.Fatalf("fields %v", info.Fields)
+	}
+	info, err := GetFieldColumns(item, "Remark", "sort")
+	if err != nil || len(info.Fields) != 3 || info.Fields[1].Value != (sql.NullString{}) || info.Fields[2].Value != -99 {
+		t.Fatalf("columns %v %v", info, err)
+	}
+	if _, err := GetFieldColumns(item, "none"); err == nil {
+		t.Errorf("unknown column")
+	}
+	now := time.Now()
+	AddUpdateFill("created", func() any { return now })
+	defer delete(updateFill, "created")
+	info, err = GetFieldColumns(item, "sort")
+	if err != nil || len(info.Fields) != 3 || info.Fields[2].TagDB != "created" || !item.Created.Equal(now) {
+		t.Fatalf("update fill %v %v", info, err)
+	}
+	item.Created = time.Time{}
+	info, err = GetFieldColumns(item, "created")
+	if err != nil || len(info.Fields) != 2 || info.Fields[1].Value != (time.Time{}) {
+		t.Fatalf("explicit column %v %v", info, err)
+	}
+}
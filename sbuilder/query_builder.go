@@ -0,0 +1,197 @@
+package sbuilder
+
+import (
+	"strings"
+)
+
+// 查询构建器：生成完整的 select 语句（? 占位符），结果可直接用于 Sorm.Select、Sorm.SelectPage
+type QueryBuilder struct {
+	dialect Dialect
+	columns []string
+	from    string
+	joins   []joinClause
+	where   []Condition
+	groupBy []string
+	having  []Condition
+	orderBy []string
+	limit   int64
+	offset  int64
+	// 为true时不追加租户条件
+	ignoreTenant bool
+}
+
+type joinClause struct {
+	kind   string
+	table  string
+	on     string
+	values []interface{}
+}
+
+// 创建查询构建器，未指定查询列时为 *
+func Select(columns ...string) *QueryBuilder {
+	return &QueryBuilder{dialect: DefaultDialect(), columns: columns}
+}
+
+// 指定当前构建器使用的数据库方言
+func (m *QueryBuilder) WithDialect(d Dialect) *QueryBuilder {
+	if d != nil {
+		m.dialect = d
+	}
+	return m
+}
+
+// 获取当前构建器使用的数据库方言
+func (m *QueryBuilder) GetDialect() Dialect {
+	return m.dialect
+}
+
+// 追加查询列
+func (m *QueryBuilder) Select(columns ...string) *QueryBuilder {
+	m.columns = append(m.columns, columns...)
+	return m
+}
+
+// 查询表，可带别名：sys_user u
+func (m *QueryBuilder) From(table string) *QueryBuilder {
+	m.from = table
+	return m
+}
+
+// 内连接，连接条件通过 On 指定
+func (m *QueryBuilder) Join(table string) *QueryBuilder {
+	return m.join("join", table)
+}
+
+// 左连接，连接条件通过 On 指定
+func (m *QueryBuilder) LeftJoin(table string) *QueryBuilder {
+	return m.join("left join", table)
+}
+
+// 右连接，连接条件通过 On 指定
+func (m *QueryBuilder) RightJoin(table string) *QueryBuilder {
+	return m.join("right join", table)
+}
+
+func (m *QueryBuilder) join(kind string, table string) *QueryBuilder {
+	m.joins = append(m.joins, joinClause{kind: kind, table: table})
+	return m
+}
+
+// 最近一个连接的连接条件：u.id = r.user_id
+func (m *QueryBuilder) On(on string, values ...interface{}) *QueryBuilder {
+	if len(m.joins) > 0 {
+		last := &m.joins[len(m.joins)-1]
+		last.on = on
+		last.values = values
+	}
+	return m
+}
+
+// 不追加租户条件
+func (m *QueryBuilder) IgnoreTenant() *QueryBuilder {
+	m.ignoreTenant = true
+	return m
+}
+
+// 追加查询条件，多次调用之间为 and 关系
+func (m *QueryBuilder) Where(conds ...Condition) *QueryBuilder {
+	m.where = append(m.where, conds...)
+	return m
+}
+
+// 分组列
+func (m *QueryBuilder) GroupBy(columns ...string) *QueryBuilder {
+	m.groupBy = append(m.groupBy, columns...)
+	return m
+}
+
+// 分组条件，多次调用之间为 and 关系
+func (m *QueryBuilder) Having(conds ...Condition) *QueryBuilder {
+	m.having = append(m.having, conds...)
+	return m
+}
+
+// 排序：OrderBy("id desc", "name")
+func (m *QueryBuilder) OrderBy(columns ...string) *QueryBuilder {
+	m.orderBy = append(m.orderBy, columns...)
+	return m
+}
+
+// 返回条数，大于0时生效
+func (m *QueryBuilder) Limit(limit int64) *QueryBuilder {
+	m.limit = limit
+	return m
+}
+
+// 跳过条数，需配合 Limit 使用
+func (m *QueryBuilder) Offset(offset int64) *QueryBuilder {
+	m.offset = offset
+	return m
+}
+
+// 生成SQL及参数
+func (m *QueryBuilder) Build() (string, []interface{}) {
+	sql := strings.Builder{}
+	values := make([]interface{}, 0)
+	columns := "*"
+	if len(m.columns) > 0 {
+		columns = strings.Join(m.columns, ", ")
+	}
+	sql.WriteString("select " + columns + " from " + m.from)
+	for _, v := range m.joins {
+		sql.WriteString(" " + v.kind + " " + v.table)
+		on, vs := v.on, v.values
+		// 连接表的租户条件放在 on 中，不改变外连接语义
+		if c := m.tenant(v.table); c != nil {
+			if ts, tv := c.Build(m.dialect); on == "" {
+				on, vs = ts, tv
+			} else {
+				on, vs = "("+on+") and "+ts, append(append(make([]interface{}, 0, len(vs)+len(tv)), vs...), tv...)
+			}
+		}
+		if on != "" {
+			sql.WriteString(" on " + on)
+			values = append(values, vs...)
+		}
+	}
+	where := append(make([]Condition, 0, len(m.where)+1), m.where...)
+	where = append(where, m.tenant(m.from))
+	if where, vs := (&group{"and", where}).clause(m.dialect); where != "" {
+		sql.WriteString(" where " + where)
+		values = append(values, vs...)
+	}
+	if len(m.groupBy) > 0 {
+		sql.WriteString(" group by " + strings.Join(m.groupBy, ", "))
+	}
+	if having, vs := (&group{"and", m.having}).clause(m.dialect); having != "" {
+		sql.WriteString(" having " + having)
+		values = append(values, vs...)
+	}
+	orderBy := ""
+	if len(m.orderBy) > 0 {
+		orderBy = "order by " + strings.Join(m.orderBy, ", ")
+	}
+	if m.limit > 0 {
+		page, vs := m.dialect.Page(sql.String(), orderBy, m.limit, m.offset)
+		return page, append(values, vs...)
+	}
+	if orderBy != "" {
+		sql.WriteString(" " + orderBy)
+	}
+	return sql.String(), values
+}
+
+// 表的租户条件，使用别名（没有别名时使用表名）限定列
+func (m *QueryBuilder) tenant(from string) Condition {
+	if m.ignoreTenant {
+		return nil
+	}
+	table, alias := tableAlias(from)
+	if table == "" {
+		return nil
+	}
+	if alias == "" {
+		alias = table
+	}
+	return tenantCondition(table, alias, m.dialect)
+}
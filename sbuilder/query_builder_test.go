@@ -0,0 +1,23 @@
+package sbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	sub := Select("user_id").From("sys_user_role").Where(Col("role_id").Eq("r1"))
+	q := Select("u.id", "count(*) n").From("sys_user u").
+		LeftJoin("sys_dept d").On("d.id = u.dept_id and d.state = ?", "1").
+		Where(Col("u.id").In(sub), Or(Col("u.name").Eq("a"), Col("u.name").Eq("b"))).
+		GroupBy("u.id").Having(Expr("count(*) > ?", 1)).OrderBy("u.id desc").Limit(10).Offset(20).
+		WithDialect(GetDialect(Postgres))
+	sql, values := q.Build()
+	want := "select u.id, count(*) n from sys_user u left join sys_dept d on d.id = u.dept_id and d.state = ? where u.id in(select user_id from sys_user_role where role_id = ?) and (u.name = ? or u.name = ?) group by u.id having (count(*) > ?) order by u.id desc LIMIT ? OFFSET ?"
+	if sql != want {
+		t.Errorf("sql\n%s\n%s", sql, want)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"1", "r1", "a", "b", 1, int64(10), int64(20)}) {
+		t.Errorf("values %v", values)
+	}
+}
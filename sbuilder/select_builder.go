@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/androidsr/sc-go/sc"
-
 	"github.com/opentracing/opentracing-go/log"
 )
 
@@ -29,22 +27,32 @@ const (
 )
 
 type SelectBuilder struct {
-	Sql    bytes.Buffer
-	link   string
-	Values []interface{}
-	links  bool
+	Sql     bytes.Buffer
+	link    string
+	Values  []interface{}
+	links   bool
+	dialect Dialect
 }
 
 func StructToBuilder(obj interface{}, sql string) *SelectBuilder {
+	return structToBuilder(obj, sql, false)
+}
+
+// 同 StructToBuilder，但不追加逻辑删除的未删除条件
+func StructToBuilderUnscoped(obj interface{}, sql string) *SelectBuilder {
+	return structToBuilder(obj, sql, true)
+}
+
+func structToBuilder(obj interface{}, sql string, unscoped bool) *SelectBuilder {
 	info := GetField(obj, 0)
-	_, values := info.GetDbValues(QUERY)
+	info.Unscoped = unscoped
 	if sql == "" {
-		sql = fmt.Sprintf("select * from %s where 1=1 ", info.TableName)
+		sql = fmt.Sprintf("select * from %s where 1=1 ", DefaultDialect().Quote(info.TableName))
 	}
 	condi := BuildQuery(info)
 	sql += condi.Sql.String()
 	builder := Builder(sql)
-	builder.Values = values
+	builder.Values = condi.Values
 	return builder
 }
 
@@ -54,6 +62,7 @@ func Builder(sql string) *SelectBuilder {
 	builder.link = "and"
 	builder.links = false
 	builder.Values = make([]interface{}, 0)
+	builder.dialect = DefaultDialect()
 	if sql != "" {
 		if !strings.Contains(sql, " where ") {
 			builder.Sql.WriteString(" where 1=1 ")
@@ -62,226 +71,105 @@ func Builder(sql string) *SelectBuilder {
 	return builder
 }
 
-func (m *SelectBuilder) IsNull(column string) string {
-	sql := fmt.Sprintf("  %s (%s is null or %s = '') ", m.link, column, column)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
+// 指定当前构建器使用的数据库方言
+func (m *SelectBuilder) WithDialect(d Dialect) *SelectBuilder {
+	if d != nil {
+		m.dialect = d
 	}
-	return ""
+	return m
 }
 
-func (m *SelectBuilder) IsNotNull(column string) string {
-	sql := fmt.Sprintf(" %s (%s is not null and %s != '') ", m.link, column, column)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+// 获取当前构建器使用的数据库方言
+func (m *SelectBuilder) GetDialect() Dialect {
+	return m.dialect
 }
 
-func (m *SelectBuilder) Eq(column string, value interface{}) string {
-	if value == nil || value == "" {
+// 追加条件，Multiple 模式下返回条件SQL由 Ands/Ors 组装
+func (m *SelectBuilder) add(c Condition) string {
+	sql, values := c.Build(m.dialect)
+	if sql == "" {
 		return ""
 	}
-	sql := fmt.Sprintf(" %s %s = ? ", m.link, column)
-	m.Values = append(m.Values, value)
+	sql = fmt.Sprintf(" %s %s ", m.link, sql)
+	m.Values = append(m.Values, values...)
 	if m.links {
 		return sql
-	} else {
-		m.Sql.WriteString(sql)
 	}
+	m.Sql.WriteString(sql)
 	return ""
 }
 
+// 追加条件树，多个条件之间为 and 关系
+func (m *SelectBuilder) Where(conds ...Condition) *SelectBuilder {
+	links := m.links
+	m.links = false
+	m.add(And(conds...))
+	m.links = links
+	return m
+}
+
+func (m *SelectBuilder) IsNull(column string) string {
+	return m.add(Col(column).IsNull())
+}
+
+func (m *SelectBuilder) IsNotNull(column string) string {
+	return m.add(Col(column).IsNotNull())
+}
+
+func (m *SelectBuilder) Eq(column string, value interface{}) string {
+	return m.add(Col(column).Eq(value))
+}
+
 func (m *SelectBuilder) Ne(column string, value interface{}) string {
-	if value == nil || value == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s <> ? ", m.link, column)
-	m.Values = append(m.Values, value)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).Ne(value))
 }
 
 func (m *SelectBuilder) In(column string, value interface{}) string {
-	if value == nil {
-		return ""
-	}
-	v := sc.AssertSliceType(value)
-	if len(v) != 0 {
-		sql := fmt.Sprintf(" %s %s in(%s) ", m.link, column, Placeholders(len(v)))
-		m.Values = append(m.Values, v...)
-		if m.links {
-			return sql
-		} else {
-			m.Sql.WriteString(sql)
-		}
-	}
-	return ""
+	return m.add(Col(column).In(value))
 }
 
 func (m *SelectBuilder) NotIn(column string, value interface{}) string {
-	if value == nil {
-		return ""
-	}
-	v := sc.AssertSliceType(value)
-	if len(v) != 0 {
-		sql := fmt.Sprintf(" %s %s not in(%s) ", m.link, column, Placeholders(len(v)))
-		m.Values = append(m.Values, v...)
-		if m.links {
-			return sql
-		} else {
-			m.Sql.WriteString(sql)
-		}
-	}
-	return ""
+	return m.add(Col(column).NotIn(value))
 }
 
 func (m *SelectBuilder) Gt(column string, value interface{}) string {
-	if value == nil || value == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s > ? ", m.link, column)
-	m.Values = append(m.Values, value)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).Gt(value))
 }
 
 func (m *SelectBuilder) Lt(column string, value interface{}) string {
-	if value == nil || value == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s < ? ", m.link, column)
-	m.Values = append(m.Values, value)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).Lt(value))
 }
 
 func (m *SelectBuilder) Ge(column string, value interface{}) string {
-	if value == nil || value == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s >= ? ", m.link, column)
-	m.Values = append(m.Values, value)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).Ge(value))
 }
 
 func (m *SelectBuilder) Le(column string, value interface{}) string {
-	if value == nil || value == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s <= ? ", m.link, column)
-	m.Values = append(m.Values, value)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).Le(value))
 }
 
 func (m *SelectBuilder) Between(column string, value BetweenInfo) string {
-	if &value == nil || value.Left == nil || value.Left == "" || value.Right == nil || value.Right == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s between ? and ? ", m.link, column)
-	m.Values = append(m.Values, value.Left, value.Right)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).Between(value))
 }
 
 func (m *SelectBuilder) NotBetween(column string, value BetweenInfo) string {
-	if &value == nil || value.Left == nil || value.Left == "" || value.Right == nil || value.Right == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s not between ? and ? ", m.link, column)
-	m.Values = append(m.Values, value.Left, value.Right)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).NotBetween(value))
 }
 
 func (m *SelectBuilder) Like(column string, value interface{}) string {
-	if value == nil || value == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s like CONCAT('%s', ?, '%s') ", m.link, column, "%", "%")
-	m.Values = append(m.Values, value)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).Like(value))
 }
 
 func (m *SelectBuilder) NotLike(column string, value interface{}) string {
-	if value == nil || value == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s not like CONCAT('%s', ?, '%s') ", m.link, column, "%", "%")
-	m.Values = append(m.Values, value)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).NotLike(value))
 }
 
 func (m *SelectBuilder) LikeLeft(column string, value interface{}) string {
-	if value == nil || value == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s not like CONCAT('%s', ?) ", m.link, column, "%")
-	m.Values = append(m.Values, value)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).LikeLeft(value))
 }
 
 func (m *SelectBuilder) LikeRight(column string, value interface{}) string {
-	if value == nil || value == "" {
-		return ""
-	}
-	sql := fmt.Sprintf(" %s %s not like CONCAT(?, '%s') ", m.link, column, "%")
-	m.Values = append(m.Values, value)
-	if m.links {
-		return sql
-	} else {
-		m.Sql.WriteString(sql)
-	}
-	return ""
+	return m.add(Col(column).LikeRight(value))
 }
 
 func (m *SelectBuilder) And() *SelectBuilder {
@@ -294,30 +182,12 @@ func (m *SelectBuilder) Or() *SelectBuilder {
 	return m
 }
 
+// 将 Multiple 模式下收集的条件SQL用 and 组合为一组
 func (m *SelectBuilder) Ands(sql ...string) *SelectBuilder {
 	if !m.links {
 		log.Error(errors.New("调用Ands方法时，需先调用Multiple方法进行多条件组装"))
 	}
-	if len(sql) == 0 || len(sql[0]) == 0 {
-		return m
-	}
-	m.link = "and"
-	m.Sql.WriteString(" and (")
-	for i, v := range sql {
-		fmt.Println("执行and")
-		if v == "" {
-			continue
-		}
-		if i == 0 {
-			v = strings.Replace(v, " and ", "", 1)
-			v = strings.Replace(v, " or ", "", 1)
-		}
-		m.Sql.WriteString(fmt.Sprintf("%s ", v))
-	}
-	m.Sql.WriteString(") ")
-	m.link = "and"
-	m.links = false
-	return m
+	return m.group("and", sql)
 }
 
 func (m *SelectBuilder) Multiple() *SelectBuilder {
@@ -325,35 +195,46 @@ func (m *SelectBuilder) Multiple() *SelectBuilder {
 	return m
 }
 
+// 将 Multiple 模式下收集的条件SQL用 or 组合为一组
 func (m *SelectBuilder) Ors(sql ...string) *SelectBuilder {
 	if !m.links {
 		log.Error(errors.New("调用Ors方法时，需先调用Multiple方法进行多条件组装"))
 	}
-	if len(sql) == 0 || len(sql[0]) == 0 {
-		m.links = false
-		return m
-	}
-	m.link = "or"
-	m.Sql.WriteString(" or (")
-	for i, v := range sql {
-		fmt.Println("执行or", v)
+	return m.group("or", sql)
+}
+
+// 条件SQL已带前导 and/or，第一个条件去掉前导连接符；参数已在生成条件时按顺序加入
+func (m *SelectBuilder) group(link string, sql []string) *SelectBuilder {
+	m.link = "and"
+	m.links = false
+	parts := make([]string, 0, len(sql))
+	for _, v := range sql {
+		v = strings.TrimSpace(v)
 		if v == "" {
 			continue
 		}
-		if i == 0 {
-			if i == 0 {
-				v = strings.Replace(v, " and ", "", 1)
-				v = strings.Replace(v, " or ", "", 1)
-			}
+		if len(parts) == 0 {
+			v = trimLink(v)
 		}
-		m.Sql.WriteString(fmt.Sprintf("%s ", v))
+		parts = append(parts, v)
 	}
-	m.Sql.WriteString(") ")
-	m.link = "and"
-	m.links = false
+	if len(parts) == 0 {
+		return m
+	}
+	m.Sql.WriteString(fmt.Sprintf(" %s (%s) ", link, strings.Join(parts, " ")))
 	return m
 }
 
+// 去掉条件SQL的前导 and/or
+func trimLink(sql string) string {
+	for _, link := range []string{"and ", "or "} {
+		if strings.HasPrefix(sql, link) {
+			return strings.TrimSpace(sql[len(link):])
+		}
+	}
+	return sql
+}
+
 func (m *SelectBuilder) Append(sql string) *SelectBuilder {
 	m.Sql.WriteString(" " + sql)
 	return m
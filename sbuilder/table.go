@@ -0,0 +1,84 @@
+package sbuilder
+
+import (
+	"reflect"
+	"sync"
+)
+
+// 自定义表名：实体实现后使用返回的表名，不再添加全局表前缀
+type Tabler interface {
+	TableName() string
+}
+
+// 分表规则：按实体字段值（创建日期、租户ID等）返回实际表名，返回空字符串时使用逻辑表名
+type ShardingFunc func(table string, obj interface{}) string
+
+var (
+	// 全局表前缀，未实现 Tabler 的实体生成表名时添加
+	tablePrefix string
+	// 逻辑表名 -> 分表规则
+	shardings = make(map[string]ShardingFunc)
+	tableLock sync.RWMutex
+)
+
+// 设置全局表前缀
+func SetTablePrefix(prefix string) {
+	tableLock.Lock()
+	defer tableLock.Unlock()
+	tablePrefix = prefix
+}
+
+// 注册分表规则，table 为逻辑表名（含前缀或 TableName 返回的表名）
+func RegisterSharding(table string, fn ShardingFunc) {
+	tableLock.Lock()
+	defer tableLock.Unlock()
+	if fn == nil {
+		delete(shardings, table)
+		return
+	}
+	shardings[table] = fn
+}
+
+// 按分表规则获取实际表名，未注册规则时返回逻辑表名
+func ResolveTable(table string, obj interface{}) string {
+	tableLock.RLock()
+	fn := shardings[table]
+	tableLock.RUnlock()
+	if fn == nil {
+		return table
+	}
+	if v := fn(table, obj); v != "" {
+		return v
+	}
+	return table
+}
+
+// 获取实体对应的实际表名：TableName() > 全局前缀+结构体名下划线 > 分表规则
+func TableName(obj interface{}) string {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	return tableName(v, getStructMeta(v.Type()))
+}
+
+func tableName(v reflect.Value, meta *structMeta) string {
+	tableLock.RLock()
+	table := tablePrefix + meta.tableName
+	tableLock.RUnlock()
+	var obj interface{}
+	if v.CanAddr() {
+		obj = v.Addr().Interface()
+	} else {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		obj = p.Interface()
+	}
+	if t, ok := obj.(Tabler); ok {
+		table = t.TableName()
+	}
+	return ResolveTable(table, obj)
+}
@@ -0,0 +1,41 @@
+package sbuilder
+
+import (
+	"testing"
+	"time"
+)
+
+type SysDict struct {
+	Id string `db:"id"`
+}
+
+type SysOrder struct {
+	Id         string    `db:"id"`
+	CreateTime time.Time `db:"create_time"`
+}
+
+func (*SysOrder) TableName() string {
+	return "biz_order"
+}
+
+func TestTableName(t *testing.T) {
+	SetTablePrefix("t_")
+	defer SetTablePrefix("")
+	if v := GetField(SysDict{Id: "1"}, 0).TableName; v != "t_sys_dict" {
+		t.Fatal(v)
+	}
+	RegisterSharding("biz_order", func(table string, obj interface{}) string {
+		if v := obj.(*SysOrder).CreateTime; !v.IsZero() {
+			return table + "_" + v.Format("200601")
+		}
+		return ""
+	})
+	defer RegisterSharding("biz_order", nil)
+	if v := TableName(SysOrder{}); v != "biz_order" {
+		t.Fatal(v)
+	}
+	order := &SysOrder{Id: "1", CreateTime: time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)}
+	if v := GetField(order, 0).TableName; v != "biz_order_202610" {
+		t.Fatal(v)
+	}
+}
@@ -0,0 +1,75 @@
+package sbuilder
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	// 租户列，为空时不处理租户
+	tenantColumn string
+	// 当前租户ID，没有时返回nil
+	tenantProvider func() interface{}
+	// 不处理租户的表
+	tenantIgnore = make(map[string]bool)
+	tenantLock   sync.RWMutex
+)
+
+// 开启多租户：查询、更新、删除追加 column = 当前租户ID 条件，插入时填充租户列；column 为空时关闭
+func SetTenant(column string, provider func() interface{}, ignore ...string) {
+	tenantLock.Lock()
+	defer tenantLock.Unlock()
+	tenantColumn = column
+	tenantProvider = provider
+	tenantIgnore = make(map[string]bool)
+	for _, v := range ignore {
+		tenantIgnore[v] = true
+	}
+}
+
+// 表的租户列及当前租户ID：不处理租户时 column 为空，需处理但没有当前租户时 value 为nil
+func Tenant(table string) (column string, value interface{}) {
+	tenantLock.RLock()
+	column, provider := tenantColumn, tenantProvider
+	ignore := tenantIgnore[table]
+	tenantLock.RUnlock()
+	if column == "" || ignore {
+		return "", nil
+	}
+	if provider != nil {
+		value = provider()
+	}
+	if value == "" {
+		value = nil
+	}
+	return column, value
+}
+
+// 租户条件，alias 为表别名；没有当前租户时条件恒为假，避免越权访问
+func tenantCondition(table string, alias string, d Dialect) Condition {
+	column, value := Tenant(table)
+	if column == "" {
+		return nil
+	}
+	if value == nil {
+		return Expr("1 = 0")
+	}
+	column = d.Quote(column)
+	if alias != "" {
+		column = alias + "." + column
+	}
+	return Col(column).Eq(value)
+}
+
+// 解析 from、join 的表名及别名：sys_user u、sys_user as u；子查询返回空表名
+func tableAlias(s string) (string, string) {
+	items := strings.Fields(s)
+	if len(items) == 0 || strings.Contains(items[0], "(") {
+		return "", ""
+	}
+	table := strings.Trim(items[0], "`\"[]")
+	if len(items) == 1 {
+		return table, ""
+	}
+	return table, items[len(items)-1]
+}
@@ -0,0 +1,32 @@
+package sbuilder
+
+import (
+	"testing"
+)
+
+func TestTenant(t *testing.T) {
+	var tenant interface{} = "t1"
+	SetTenant("tenant_id", func() interface{} { return tenant }, "sys_dict")
+	defer SetTenant("", nil)
+	d := GetDialect("mysql")
+	sql, values := Update("sys_user").WithDialect(d).Set("name", "a").Where(Col("id").Eq(1)).Build()
+	if sql != "update `sys_user` set `name` = ? where id = ? and `tenant_id` = ?" || len(values) != 3 || values[2] != "t1" {
+		t.Fatal(sql, values)
+	}
+	sql, values = InsertInto("sys_user").WithDialect(d).Columns("id").Values(1).Build()
+	if sql != "insert into `sys_user`(`id`, `tenant_id`) values (?, ?)" || values[1] != "t1" {
+		t.Fatal(sql, values)
+	}
+	sql, _ = Select().From("sys_user u").Join("sys_dict d").On("d.id = u.dict_id").WithDialect(d).Build()
+	if sql != "select * from sys_user u join sys_dict d on d.id = u.dict_id where u.`tenant_id` = ?" {
+		t.Fatal(sql)
+	}
+	tenant = nil
+	sql, _ = DeleteFrom("sys_user").WithDialect(d).Where(Col("id").Eq(1)).Build()
+	if sql != "delete from `sys_user` where id = ? and (1 = 0)" {
+		t.Fatal(sql)
+	}
+	if sql, _ = DeleteFrom("sys_user").WithDialect(d).IgnoreTenant().Build(); sql != "delete from `sys_user`" {
+		t.Fatal(sql)
+	}
+}
@@ -0,0 +1,113 @@
+package sgen
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+// 表结构
+type Table struct {
+	Name    string
+	Comment string
+	Columns []Column
+}
+
+// 列结构
+type Column struct {
+	Name       string
+	Type       string
+	Nullable   bool
+	PrimaryKey bool
+	Comment    string
+}
+
+// 读取表名及注释
+func (m *Generator) Tables(ctx context.Context) ([]Table, error) {
+	var query string
+	switch m.dialect.Name() {
+	case sbuilder.MySQL:
+		query = "select table_name, table_comment from information_schema.tables where table_schema = database() and table_type = 'BASE TABLE' order by table_name"
+	case sbuilder.Postgres:
+		query = "select table_name, coalesce(obj_description(format('%I.%I', table_schema, table_name)::regclass), '') from information_schema.tables where table_schema = current_schema() and table_type = 'BASE TABLE' order by table_name"
+	case sbuilder.SQLite:
+		query = "select name, '' from sqlite_master where type = 'table' and name not like 'sqlite_%' order by name"
+	default:
+		return nil, fmt.Errorf("不支持的数据库: %s", m.dialect.Name())
+	}
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]Table, 0)
+	for rows.Next() {
+		var name string
+		var comment sql.NullString
+		if err := rows.Scan(&name, &comment); err != nil {
+			return nil, err
+		}
+		result = append(result, Table{Name: name, Comment: comment.String})
+	}
+	return result, rows.Err()
+}
+
+// 读取表的列结构，按定义顺序
+func (m *Generator) Columns(ctx context.Context, table string) ([]Column, error) {
+	switch m.dialect.Name() {
+	case sbuilder.MySQL:
+		return m.scanColumns(ctx, "select column_name, column_type, is_nullable = 'YES', column_key = 'PRI', column_comment from information_schema.columns where table_schema = database() and table_name = ? order by ordinal_position", table)
+	case sbuilder.Postgres:
+		return m.scanColumns(ctx, `select c.column_name, c.udt_name, c.is_nullable = 'YES',
+			exists (select 1 from information_schema.table_constraints t join information_schema.key_column_usage k
+				on t.constraint_name = k.constraint_name and t.table_schema = k.table_schema and t.table_name = k.table_name
+				where t.constraint_type = 'PRIMARY KEY' and t.table_schema = c.table_schema and t.table_name = c.table_name and k.column_name = c.column_name),
+			coalesce(col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position), '')
+			from information_schema.columns c where c.table_schema = current_schema() and c.table_name = ? order by c.ordinal_position`, table)
+	case sbuilder.SQLite:
+		return m.sqliteColumns(ctx, table)
+	}
+	return nil, fmt.Errorf("不支持的数据库: %s", m.dialect.Name())
+}
+
+func (m *Generator) scanColumns(ctx context.Context, query string, table string) ([]Column, error) {
+	rows, err := m.db.QueryContext(ctx, m.dialect.Rebind(query), table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]Column, 0)
+	for rows.Next() {
+		var item Column
+		var comment sql.NullString
+		if err := rows.Scan(&item.Name, &item.Type, &item.Nullable, &item.PrimaryKey, &comment); err != nil {
+			return nil, err
+		}
+		item.Comment = comment.String
+		result = append(result, item)
+	}
+	return result, rows.Err()
+}
+
+// SQLite 通过 pragma table_info 读取列结构
+func (m *Generator) sqliteColumns(ctx context.Context, table string) ([]Column, error) {
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf("pragma table_info(%s)", m.dialect.Quote(table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]Column, 0)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var value sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &value, &pk); err != nil {
+			return nil, err
+		}
+		result = append(result, Column{Name: name, Type: strings.ToLower(typ), Nullable: notNull == 0 && pk == 0, PrimaryKey: pk > 0})
+	}
+	return result, rows.Err()
+}
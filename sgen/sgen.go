@@ -0,0 +1,343 @@
+package sgen
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/androidsr/sc-go/sbuilder"
+	"github.com/androidsr/sc-go/sc"
+	"github.com/androidsr/sc-go/syaml"
+)
+
+// 实体代码生成器：按表结构生成带 db、json、keyword tag 的实体
+type Generator struct {
+	db      *sql.DB
+	dialect sbuilder.Dialect
+}
+
+// 生成选项
+type Options struct {
+	// 输出目录
+	Dir string
+	// 包名，默认取输出目录名
+	Package string
+	// 生成的表，为空时生成全部表
+	Tables []string
+	// 生成类型名时去掉的表前缀，与配置的 tablePrefix 一致
+	Prefix string
+	// 生成查询DTO（内嵌 model.PageInfo）
+	Query bool
+	// 生成数据操作对象（sorm.Repo）
+	Repo bool
+	// 生成 sgin controller，需同时生成查询DTO及数据操作对象
+	Controller bool
+	// 接口路径前缀：/api
+	Route string
+}
+
+// 创建生成器，按配置连接数据库
+func New(config *syaml.SqlxInfo) (*Generator, error) {
+	db, err := sql.Open(config.Driver, config.Url)
+	if err != nil {
+		log.Printf("数据库初始化失败:%s", err.Error())
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		log.Printf("数据库连接异常：%s", err.Error())
+		db.Close()
+		return nil, err
+	}
+	return &Generator{db: db, dialect: sbuilder.GetDialect(config.Driver)}, nil
+}
+
+// 关闭数据库连接
+func (m *Generator) Close() error {
+	return m.db.Close()
+}
+
+// 执行生成命令：-dir 输出目录 -pkg 包名 -tables 表名（逗号分隔） -prefix 表前缀 -query -repo -controller -route 接口前缀
+func Command(config *syaml.SqlxInfo, args []string) error {
+	opts := Options{}
+	var tables string
+	set := flag.NewFlagSet("gen", flag.ContinueOnError)
+	set.StringVar(&opts.Dir, "dir", "entity", "输出目录")
+	set.StringVar(&opts.Package, "pkg", "", "包名，默认取输出目录名")
+	set.StringVar(&tables, "tables", "", "生成的表，逗号分隔，为空时生成全部表")
+	set.StringVar(&opts.Prefix, "prefix", "", "生成类型名时去掉的表前缀")
+	set.BoolVar(&opts.Query, "query", false, "生成查询DTO")
+	set.BoolVar(&opts.Repo, "repo", false, "生成数据操作对象")
+	set.BoolVar(&opts.Controller, "controller", false, "生成controller")
+	set.StringVar(&opts.Route, "route", "", "接口路径前缀")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if tables != "" {
+		opts.Tables = strings.Split(tables, ",")
+	}
+	g, err := New(config)
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+	return g.Generate(context.Background(), opts)
+}
+
+// 按选项生成代码文件，每个表一个文件，controller 单独一个文件
+func (m *Generator) Generate(ctx context.Context, opts Options) error {
+	if opts.Controller && (!opts.Query || !opts.Repo) {
+		return errors.New("生成controller需同时生成查询DTO及数据操作对象")
+	}
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if opts.Package == "" {
+		abs, err := filepath.Abs(opts.Dir)
+		if err != nil {
+			return err
+		}
+		opts.Package = strings.ReplaceAll(filepath.Base(abs), "-", "_")
+	}
+	tables, err := m.Tables(ctx)
+	if err != nil {
+		log.Printf("读取表结构失败: %v", err)
+		return err
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return err
+	}
+	n := 0
+	for _, table := range tables {
+		if len(opts.Tables) > 0 && !contains(opts.Tables, table.Name) || len(opts.Tables) == 0 && table.Name == "schema_migrations" {
+			continue
+		}
+		if table.Columns, err = m.Columns(ctx, table.Name); err != nil {
+			log.Printf("读取表结构失败: %v", err)
+			return err
+		}
+		files, err := Render(table, opts)
+		if err != nil {
+			return err
+		}
+		for name, bs := range files {
+			if err := os.WriteFile(filepath.Join(opts.Dir, name), bs, 0644); err != nil {
+				return err
+			}
+			log.Printf("生成文件: %s", filepath.Join(opts.Dir, name))
+		}
+		n++
+	}
+	if n == 0 {
+		return errors.New("没有需要生成的表")
+	}
+	return nil
+}
+
+// 生成单个表的代码：文件名 -> 格式化后的代码
+func Render(table Table, opts Options) (map[string][]byte, error) {
+	data := newEntity(table, opts)
+	files := make(map[string][]byte)
+	bs, err := execute(entityTpl, data)
+	if err != nil {
+		return nil, err
+	}
+	files[table.Name+".go"] = bs
+	if opts.Controller {
+		if bs, err = execute(controllerTpl, data); err != nil {
+			return nil, err
+		}
+		files[table.Name+"_controller.go"] = bs
+	}
+	return files, nil
+}
+
+func execute(tpl *template.Template, data *entity) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := tpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	bs, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化代码失败 %s: %w", data.Table, err)
+	}
+	return bs, nil
+}
+
+// 模板数据
+type entity struct {
+	Options
+	Table   string
+	Comment string
+	Name    string
+	Path    string
+	Key     *field
+	// 主键为空的判断表达式
+	KeyEmpty string
+	Custom   bool
+	Fields   []field
+	Imports  []string
+	// 查询DTO字段
+	QueryFields []field
+}
+
+type field struct {
+	Name    string
+	Type    string
+	Tag     string
+	Comment string
+}
+
+func newEntity(table Table, opts Options) *entity {
+	name := strings.TrimPrefix(table.Name, opts.Prefix)
+	data := &entity{Options: opts, Table: table.Name, Comment: oneLine(table.Comment), Name: camel(name, true)}
+	data.Path = strings.TrimSuffix(opts.Route, "/") + "/" + camel(name, false)
+	// 按类型名推导的表名不一致时生成 TableName()
+	data.Custom = opts.Prefix+sc.GetUnderscore(data.Name) != table.Name
+	if data.Comment == "" {
+		data.Comment = table.Name
+	}
+	imports := make(map[string]bool)
+	queryImports := map[string]bool{"github.com/androidsr/sc-go/model": true}
+	for _, c := range table.Columns {
+		typ, pkg := goType(c.Type)
+		if c.Nullable && !strings.HasPrefix(typ, "[]") {
+			typ = "*" + typ
+		}
+		json := camel(c.Name, false)
+		db := c.Name
+		if c.PrimaryKey && data.Key == nil {
+			db += ",primary_key"
+		}
+		item := field{Name: camel(c.Name, true), Type: typ, Tag: fmt.Sprintf("`json:\"%s\" db:\"%s\"`", json, db), Comment: oneLine(c.Comment)}
+		if c.PrimaryKey && data.Key == nil {
+			data.Key = &item
+			data.KeyEmpty = emptyExpr("data."+item.Name, typ)
+		}
+		data.Fields = append(data.Fields, item)
+		if pkg != "" {
+			imports[pkg] = true
+		}
+		// 查询条件：字符串模糊匹配（主键除外），其它类型使用指针，未传值时不作为条件
+		if typ == "[]byte" {
+			continue
+		}
+		query := field{Name: item.Name, Type: "string", Tag: fmt.Sprintf("`json:\"%s\" db:\"%s\" keyword:\"like\"`", json, c.Name), Comment: item.Comment}
+		if base := strings.TrimPrefix(typ, "*"); base != "string" {
+			query.Type = "*" + base
+			query.Tag = fmt.Sprintf("`json:\"%s\" db:\"%s\"`", json, c.Name)
+		} else if c.PrimaryKey {
+			query.Tag = fmt.Sprintf("`json:\"%s\" db:\"%s\"`", json, c.Name)
+		}
+		data.QueryFields = append(data.QueryFields, query)
+		if pkg != "" {
+			queryImports[pkg] = true
+		}
+	}
+	if opts.Repo {
+		imports["github.com/androidsr/sc-go/sorm"] = true
+	}
+	if opts.Query {
+		for k := range queryImports {
+			imports[k] = true
+		}
+	}
+	data.Imports = sortedKeys(imports)
+	return data
+}
+
+// 值为空的判断表达式，用于生成的接口校验主键
+func emptyExpr(name string, typ string) string {
+	switch {
+	case strings.HasPrefix(typ, "*"):
+		return name + " == nil"
+	case strings.HasPrefix(typ, "[]"):
+		return "len(" + name + ") == 0"
+	case typ == "string":
+		return name + ` == ""`
+	case typ == "bool":
+		return "!" + name
+	case typ == "time.Time":
+		return name + ".IsZero()"
+	}
+	return name + " == 0"
+}
+
+// 数据库类型对应的Go类型及需要导入的包
+func goType(dbType string) (string, string) {
+	t := strings.ToLower(strings.TrimSpace(dbType))
+	switch {
+	case t == "tinyint(1)" || t == "bool" || t == "boolean":
+		return "bool", ""
+	case strings.Contains(t, "int") && !strings.HasPrefix(t, "interval") && !strings.HasPrefix(t, "point") || strings.HasPrefix(t, "serial") || strings.HasPrefix(t, "bigserial"):
+		return "int64", ""
+	case strings.HasPrefix(t, "decimal") || strings.HasPrefix(t, "numeric") || strings.HasPrefix(t, "float") ||
+		strings.HasPrefix(t, "double") || strings.HasPrefix(t, "real") || t == "money":
+		return "float64", ""
+	case strings.HasPrefix(t, "date") || strings.HasPrefix(t, "timestamp"):
+		return "time.Time", "time"
+	case strings.Contains(t, "blob") || strings.Contains(t, "binary") || t == "bytea":
+		return "[]byte", ""
+	}
+	return "string", ""
+}
+
+// 下划线命名转驼峰命名，upper 为true时首字母大写
+func camel(s string, upper bool) string {
+	b := strings.Builder{}
+	next := upper
+	for i, c := range strings.ToLower(s) {
+		switch {
+		case c == '_' || c == '-' || c == ' ':
+			next = i > 0 || upper
+		case next && c >= 'a' && c <= 'z':
+			b.WriteRune(c - 32)
+			next = false
+		default:
+			b.WriteRune(c)
+			next = false
+		}
+	}
+	return b.String()
+}
+
+// 导入包排序：标准库在前，第三方包在后，中间以空字符串分隔
+func sortedKeys(m map[string]bool) []string {
+	std := make([]string, 0, len(m))
+	other := make([]string, 0, len(m))
+	for k := range m {
+		if strings.Contains(strings.Split(k, "/")[0], ".") {
+			other = append(other, k)
+		} else {
+			std = append(std, k)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	if len(std) > 0 && len(other) > 0 {
+		std = append(std, "")
+	}
+	return append(std, other...)
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if strings.TrimSpace(v) == value {
+			return true
+		}
+	}
+	return false
+}
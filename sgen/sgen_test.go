@@ -0,0 +1,36 @@
+package sgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	table := Table{Name: "sys_user", Comment: "用户", Columns: []Column{
+		{Name: "id", Type: "varchar(32)", PrimaryKey: true},
+		{Name: "user_name", Type: "varchar(50)", Comment: "用户名"},
+		{Name: "age", Type: "int(11)", Nullable: true},
+		{Name: "create_time", Type: "datetime"},
+	}}
+	files, err := Render(table, Options{Package: "entity", Query: true, Repo: true, Controller: true, Route: "/api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity := string(files["sys_user.go"])
+	for _, v := range []string{
+		"type SysUser struct",
+		"Id         string    `json:\"id\" db:\"id,primary_key\"`",
+		"UserName   string    `json:\"userName\" db:\"user_name\"` // 用户名",
+		"Age        *int64    `json:\"age\" db:\"age\"`",
+		"UserName       string     `json:\"userName\" db:\"user_name\" keyword:\"like\"`",
+		"func NewSysUserRepo(db *sorm.Sorm) *sorm.Repo[SysUser]",
+	} {
+		if !strings.Contains(entity, v) {
+			t.Fatal(v, "\n", entity)
+		}
+	}
+	controller := string(files["sys_user_controller.go"])
+	if !strings.Contains(controller, "// @Router [post] /api/sysUser/page [json]\n") || strings.Count(controller, "if data.Id == \"\" {") != 3 {
+		t.Fatal(controller)
+	}
+}
@@ -0,0 +1,98 @@
+package sgen
+
+import (
+	"text/template"
+)
+
+var (
+	entityTpl = template.Must(template.New("entity").Parse(`// 由 sgen 根据表结构 {{.Table}} 生成
+package {{.Package}}
+{{if .Imports}}
+import (
+{{range .Imports}}{{if .}}	"{{.}}"{{end}}
+{{end}})
+{{end}}
+// {{.Comment}}
+type {{.Name}} struct {
+{{range .Fields}}	{{.Name}} {{.Type}} {{.Tag}}{{if .Comment}} // {{.Comment}}{{end}}
+{{end}}}
+{{if .Custom}}
+func ({{.Name}}) TableName() string {
+	return "{{.Table}}"
+}
+{{end}}{{if .Options.Query}}
+// {{.Comment}}查询条件
+type {{.Name}}Query struct {
+	model.PageInfo ` + "`db:\"-\"`" + `
+{{range .QueryFields}}	{{.Name}} {{.Type}} {{.Tag}}{{if .Comment}} // {{.Comment}}{{end}}
+{{end}}}
+{{end}}{{if .Options.Repo}}
+// {{.Comment}}数据操作，db 为nil时使用默认 DB
+func New{{.Name}}Repo(db *sorm.Sorm) *sorm.Repo[{{.Name}}] {
+	return sorm.NewRepo[{{.Name}}](db)
+}
+{{end}}`))
+
+	controllerTpl = template.Must(template.New("controller").Parse(`// 由 sgen 根据表结构 {{.Table}} 生成
+package {{.Package}}
+
+import (
+{{if .Key}}	"errors"
+
+{{end}}	"github.com/androidsr/sc-go/model"
+	"github.com/androidsr/sc-go/sgin"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	sgin.AddRouter({{.Name}}Controller{})
+}
+
+// {{.Comment}}接口
+type {{.Name}}Controller struct {
+}
+
+// @Router [post] {{.Path}}/page [json]
+func ({{.Name}}Controller) Page(c *gin.Context, query *{{.Name}}Query) (*model.PageResult, error) {
+	return New{{.Name}}Repo(nil).PageByContext(c.Request.Context(), query, query.PageInfo)
+}
+
+// @Router [post] {{.Path}}/add [json]
+func ({{.Name}}Controller) Add(c *gin.Context, data *{{.Name}}) (*{{.Name}}, error) {
+	if err := New{{.Name}}Repo(nil).InsertContext(c.Request.Context(), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+{{if .Key}}
+// @Router [post] {{.Path}}/get [json]
+func ({{.Name}}Controller) Get(c *gin.Context, data *{{.Name}}) (*{{.Name}}, error) {
+	if {{.KeyEmpty}} {
+		return nil, errors.New("主键不能为空")
+	}
+	return New{{.Name}}Repo(nil).FindByIdContext(c.Request.Context(), data.{{.Key.Name}})
+}
+
+// @Router [post] {{.Path}}/edit [json]
+func ({{.Name}}Controller) Edit(c *gin.Context, data *{{.Name}}) (*{{.Name}}, error) {
+	if {{.KeyEmpty}} {
+		return nil, errors.New("主键不能为空")
+	}
+	if err := New{{.Name}}Repo(nil).UpdateByIdContext(c.Request.Context(), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// @Router [post] {{.Path}}/delete [json]
+func ({{.Name}}Controller) Delete(c *gin.Context, data *{{.Name}}) (string, error) {
+	if {{.KeyEmpty}} {
+		return "", errors.New("主键不能为空")
+	}
+	if err := New{{.Name}}Repo(nil).DeleteByIdContext(c.Request.Context(), data.{{.Key.Name}}); err != nil {
+		return "", err
+	}
+	return model.OK_MSG, nil
+}
+{{end}}`))
+)
@@ -49,6 +49,22 @@ func (s *SGin) GetContent() routine.ThreadLocal[any] {
 	return threadLocal
 }
 
+// 获取当前请求的gin上下文，非请求协程返回nil
+func GetContext() *gin.Context {
+	c, _ := threadLocal.Get().(*gin.Context)
+	return c
+}
+
+// 获取当前请求上下文中的值（如JWT写入的用户信息），非请求协程或不存在时返回nil
+func GetValue(key string) interface{} {
+	c := GetContext()
+	if c == nil {
+		return nil
+	}
+	v, _ := c.Get(key)
+	return v
+}
+
 func (g *SGin) autoRegister() {
 	fmt.Printf("路由注册大小：%d\n", len(ctrls))
 	for _, ctrl := range ctrls {
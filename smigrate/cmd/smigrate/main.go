@@ -0,0 +1,56 @@
+// 数据库迁移命令行：smigrate [-config sc-go.yaml] [-orm sqlx|gorm] up | down [步数] | status
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/androidsr/sc-go/smigrate"
+	"github.com/androidsr/sc-go/syaml"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	config := flag.String("config", "sc-go.yaml", "配置文件")
+	orm := flag.String("orm", "sqlx", "使用的数据源配置：sqlx、gorm")
+	flag.Parse()
+	if err := run(*config, *orm, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(file, orm string, args []string) error {
+	root, err := syaml.LoadFile[syaml.ScRoot](file)
+	if err != nil {
+		return err
+	}
+	if root.Sc == nil {
+		return fmt.Errorf("配置文件缺少 sc 节点: %s", file)
+	}
+	var driver, url string
+	var config *syaml.MigrateInfo
+	switch {
+	case orm == "sqlx" && root.Sc.Sqlx != nil:
+		driver, url, config = root.Sc.Sqlx.Driver, root.Sc.Sqlx.Url, root.Sc.Sqlx.Migrate
+	case orm == "gorm" && root.Sc.Gorm != nil:
+		driver, url, config = root.Sc.Gorm.Driver, root.Sc.Gorm.Url, root.Sc.Gorm.Migrate
+	default:
+		return fmt.Errorf("配置文件缺少 %s 数据源", orm)
+	}
+	// postgres 使用 pgx 驱动连接
+	name := driver
+	if name == "postgres" {
+		name = "pgx"
+	}
+	db, err := sql.Open(name, url)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return smigrate.Command(db, driver, config, args, os.Stdout)
+}
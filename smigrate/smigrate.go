@@ -0,0 +1,491 @@
+package smigrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/androidsr/sc-go/sbuilder"
+	"github.com/androidsr/sc-go/syaml"
+)
+
+const (
+	// 默认已执行版本记录表
+	defaultTable = "schema_migrations"
+	// 迁移锁超过该时间未释放时视为异常退出遗留的锁
+	lockTimeout = 10 * time.Minute
+	// 等待迁移锁的重试间隔
+	lockRetry = time.Second
+)
+
+var (
+	// 迁移文件来源，未设置时从文件系统目录读取
+	source fs.FS
+	// 迁移文件名：版本_名称.up.sql、版本_名称.down.sql
+	fileReg = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+// 设置迁移文件来源，通常为 embed.FS
+func SetFS(fsys fs.FS) {
+	source = fsys
+}
+
+// 迁移文件
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// 迁移状态
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt string
+	// 执行后迁移文件被修改
+	Modified bool
+}
+
+// 已执行版本记录
+type record struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt string
+}
+
+type Migrator struct {
+	db      *sql.DB
+	dialect sbuilder.Dialect
+	fsys    fs.FS
+	dir     string
+	table   string
+}
+
+// 创建迁移对象，driver 为数据库驱动名（mysql、postgres、sqlite3 等）
+func New(db *sql.DB, driver string, config *syaml.MigrateInfo) *Migrator {
+	m := &Migrator{db: db, dialect: sbuilder.GetDialect(driver), fsys: source, dir: ".", table: defaultTable}
+	if config != nil {
+		if config.Dir != "" {
+			m.dir = config.Dir
+		}
+		if config.Table != "" {
+			m.table = config.Table
+		}
+	}
+	if m.fsys == nil {
+		m.fsys = os.DirFS(m.dir)
+		m.dir = "."
+	}
+	return m
+}
+
+// 启动时按配置执行待执行的迁移，未开启自动迁移时不处理
+func Auto(db *sql.DB, driver string, config *syaml.MigrateInfo) error {
+	if config == nil || !config.Auto {
+		return nil
+	}
+	n, err := New(db, driver, config).Up(context.Background())
+	if err != nil {
+		log.Printf("数据库迁移失败: %v", err)
+		return err
+	}
+	log.Printf("数据库迁移完成，执行%d个版本", n)
+	return nil
+}
+
+// 执行迁移命令：up、down [步数]、status，结果输出到 out
+func Command(db *sql.DB, driver string, config *syaml.MigrateInfo, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("迁移命令为空，可选：up、down [步数]、status")
+	}
+	m := New(db, driver, config)
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "执行%d个版本\n", n)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			v, err := strconv.Atoi(args[1])
+			if err != nil || v <= 0 {
+				return errors.New("回滚步数无效: " + args[1])
+			}
+			steps = v
+		}
+		n, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "回滚%d个版本\n", n)
+	case "status":
+		list, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, v := range list {
+			state := "待执行"
+			if v.Applied {
+				state = "已执行 " + v.AppliedAt
+			}
+			if v.Modified {
+				state += " （文件已修改）"
+			}
+			fmt.Fprintf(out, "%d\t%s\t%s\n", v.Version, v.Name, state)
+		}
+	default:
+		return errors.New("未知的迁移命令: " + args[0])
+	}
+	return nil
+}
+
+// 读取迁移文件，按版本升序
+func (m *Migrator) Migrations() ([]*Migration, error) {
+	entries, err := fs.ReadDir(m.fsys, m.dir)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileReg.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		bs, err := fs.ReadFile(m.fsys, path.Join(m.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		item, ok := items[version]
+		if !ok {
+			item = &Migration{Version: version, Name: match[2]}
+			items[version] = item
+		} else if item.Name != match[2] {
+			return nil, fmt.Errorf("迁移版本重复: %d", version)
+		}
+		if match[3] == "up" {
+			item.Up = string(bs)
+			sum := sha256.Sum256(bs)
+			item.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			item.Down = string(bs)
+		}
+	}
+	result := make([]*Migration, 0, len(items))
+	for _, v := range items {
+		if v.Checksum == "" {
+			return nil, fmt.Errorf("迁移缺少执行文件: %d_%s.up.sql", v.Version, v.Name)
+		}
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+// 执行所有待执行的迁移，返回执行的版本数；已执行的迁移文件被修改时不执行；多个实例同时执行时依次获取迁移锁
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	migrations, applied, err := m.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range migrations {
+		if r, ok := applied[v.Version]; ok && r.Checksum != v.Checksum {
+			return 0, fmt.Errorf("已执行的迁移文件被修改: %d_%s", v.Version, v.Name)
+		}
+	}
+	n := 0
+	for _, v := range migrations {
+		if _, ok := applied[v.Version]; ok {
+			continue
+		}
+		record := fmt.Sprintf("insert into %s (version, name, checksum, applied_at) values (?, ?, ?, ?)", m.dialect.Quote(m.table))
+		err := m.run(ctx, v.Up, record, v.Version, v.Name, v.Checksum, time.Now().Format(time.DateTime))
+		if err != nil {
+			return n, fmt.Errorf("执行迁移失败 %d_%s: %w", v.Version, v.Name, err)
+		}
+		log.Printf("执行迁移: %d_%s", v.Version, v.Name)
+		n++
+	}
+	return n, nil
+}
+
+// 按版本倒序回滚最近执行的 steps 个迁移，返回回滚的版本数
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	migrations, applied, err := m.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+	files := make(map[int64]*Migration, len(migrations))
+	for _, v := range migrations {
+		files[v.Version] = v
+	}
+	versions := make([]int64, 0, len(applied))
+	for k := range applied {
+		versions = append(versions, k)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] > versions[j]
+	})
+	n := 0
+	for _, version := range versions {
+		if n >= steps {
+			break
+		}
+		r := applied[version]
+		file, ok := files[version]
+		if !ok || strings.TrimSpace(file.Down) == "" {
+			return n, fmt.Errorf("迁移缺少回滚文件: %d_%s.down.sql", version, r.Name)
+		}
+		record := fmt.Sprintf("delete from %s where version = ?", m.dialect.Quote(m.table))
+		if err := m.run(ctx, file.Down, record, version); err != nil {
+			return n, fmt.Errorf("回滚迁移失败 %d_%s: %w", version, r.Name, err)
+		}
+		log.Printf("回滚迁移: %d_%s", version, r.Name)
+		n++
+	}
+	return n, nil
+}
+
+// 迁移状态，按版本升序；包含已执行但文件已不存在的版本
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	migrations, applied, err := m.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Status, 0, len(migrations))
+	for _, v := range migrations {
+		item := Status{Version: v.Version, Name: v.Name}
+		if r, ok := applied[v.Version]; ok {
+			item.Applied = true
+			item.AppliedAt = r.AppliedAt
+			item.Modified = r.Checksum != v.Checksum
+			delete(applied, v.Version)
+		}
+		result = append(result, item)
+	}
+	for _, r := range applied {
+		result = append(result, Status{Version: r.Version, Name: r.Name, Applied: true, AppliedAt: r.AppliedAt})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+// 获取迁移锁：在 <记录表>_lock 表中插入锁记录，已被其它实例持有时等待，返回释放锁的函数
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	table := m.dialect.Quote(m.table + "_lock")
+	create := fmt.Sprintf("create table if not exists %s (id int primary key, locked_at bigint not null)", table)
+	if _, err := m.db.ExecContext(ctx, create); err != nil {
+		log.Printf("创建迁移锁表失败: %v", err)
+		return nil, err
+	}
+	insert := m.dialect.Rebind(fmt.Sprintf("insert into %s (id, locked_at) values (1, ?)", table))
+	expire := m.dialect.Rebind(fmt.Sprintf("delete from %s where id = 1 and locked_at < ?", table))
+	// locked_at 记录纳秒时间，同时作为本实例的锁标识，释放时只删除自己写入的记录
+	// 插入失败且锁记录不存在时重试一次，仍失败则不是锁冲突
+	retried := false
+	var token int64
+	for {
+		token = time.Now().UnixNano()
+		_, err := m.db.ExecContext(ctx, insert, token)
+		if err == nil {
+			break
+		}
+		var count int
+		if e := m.db.QueryRowContext(ctx, fmt.Sprintf("select count(*) from %s", table)).Scan(&count); e != nil || (count == 0 && retried) {
+			log.Printf("获取迁移锁失败: %v", err)
+			return nil, err
+		}
+		if retried = count == 0; retried {
+			continue
+		}
+		if _, err := m.db.ExecContext(ctx, expire, time.Now().Add(-lockTimeout).UnixNano()); err != nil {
+			return nil, err
+		}
+		log.Printf("迁移锁已被其它实例持有，等待中")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetry):
+		}
+	}
+	unlock := m.dialect.Rebind(fmt.Sprintf("delete from %s where id = 1 and locked_at = ?", table))
+	return func() {
+		if _, err := m.db.Exec(unlock, token); err != nil {
+			log.Printf("释放迁移锁失败: %v", err)
+		}
+	}, nil
+}
+
+// 读取迁移文件及已执行版本，记录表不存在时创建
+func (m *Migrator) load(ctx context.Context) ([]*Migration, map[int64]*record, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		log.Printf("读取迁移文件失败: %v", err)
+		return nil, nil, err
+	}
+	table := m.dialect.Quote(m.table)
+	create := fmt.Sprintf("create table if not exists %s (version bigint primary key, name varchar(255) not null, checksum varchar(64) not null, applied_at varchar(32) not null)", table)
+	if _, err := m.db.ExecContext(ctx, create); err != nil {
+		log.Printf("创建迁移记录表失败: %v", err)
+		return nil, nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf("select version, name, checksum, applied_at from %s", table))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]*record)
+	for rows.Next() {
+		r := new(record)
+		if err := rows.Scan(&r.Version, &r.Name, &r.Checksum, &r.AppliedAt); err != nil {
+			return nil, nil, err
+		}
+		applied[r.Version] = r
+	}
+	return migrations, applied, rows.Err()
+}
+
+// 在同一事务中执行迁移语句及版本记录（MySQL 的DDL会隐式提交，失败时需手工处理）
+func (m *Migrator) run(ctx context.Context, script string, record string, values ...interface{}) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(script, m.dialect.Name() == sbuilder.MySQL) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, m.dialect.Rebind(record), values...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// 按分号拆分SQL语句，忽略引号、注释及 Postgres $$ 函数体中的分号；backslash 为 true 时（MySQL）引号内 \ 转义下一个字符
+func splitStatements(script string, backslash bool) []string {
+	result := make([]string, 0)
+	var b strings.Builder
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// 引号内原样保留，'' 视为结束后重新开始
+			j := i + 1
+			for j < len(runes) && runes[j] != c {
+				if backslash && runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			end := min(j+1, len(runes))
+			b.WriteString(string(runes[i:end]))
+			i = end - 1
+			continue
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			// 行注释不保留
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			if i < len(runes) {
+				b.WriteRune('\n')
+			}
+			continue
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			// 块注释原样保留（MySQL 的 /*! */ 为可执行注释）
+			end := closeAt(runes, i+2, []rune("*/"))
+			b.WriteString(string(runes[i:end]))
+			i = end - 1
+			continue
+		case c == '$' && (i == 0 || !isIdent(runes[i-1])):
+			// Postgres 美元符号引用：$$...$$、$tag$...$tag$
+			if tag := dollarTag(runes[i:]); tag != nil {
+				end := closeAt(runes, i+len(tag), tag)
+				b.WriteString(string(runes[i:end]))
+				i = end - 1
+				continue
+			}
+		case c == ';':
+			if stmt := strings.TrimSpace(b.String()); stmt != "" {
+				result = append(result, stmt)
+			}
+			b.Reset()
+			continue
+		}
+		b.WriteRune(c)
+	}
+	if stmt := strings.TrimSpace(b.String()); stmt != "" {
+		result = append(result, stmt)
+	}
+	return result
+}
+
+// 美元符号引用的标签，如 $$、$body$；不是标签（如 $1 参数）时返回nil
+func dollarTag(runes []rune) []rune {
+	for i := 1; i < len(runes); i++ {
+		c := runes[i]
+		if c == '$' {
+			return runes[:i+1]
+		}
+		if !isIdent(c) || (i == 1 && unicode.IsDigit(c)) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// 标识符字符
+func isIdent(c rune) bool {
+	return c == '_' || c == '$' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// 从 from 开始查找结束符 sub，返回结束符之后的位置，未闭合时返回末尾
+func closeAt(runes []rune, from int, sub []rune) int {
+	for i := from; i+len(sub) <= len(runes); i++ {
+		if string(runes[i:i+len(sub)]) == string(sub) {
+			return i + len(sub)
+		}
+	}
+	return len(runes)
+}
@@ -0,0 +1,123 @@
+package smigrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/androidsr/sc-go/syaml"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSplitStatements(t *testing.T) {
+	list := splitStatements("create table a (s text default 'x;y'); -- 注释;\ninsert into a values (\"a;b\");\n", false)
+	if len(list) != 2 || list[0] != "create table a (s text default 'x;y')" || list[1] != "insert into a values (\"a;b\")" {
+		t.Fatal(list)
+	}
+	list = splitStatements("/* a; b */ select 1; create function f() returns int as $$ begin return 1; end; $$ language plpgsql; select $1 from a$b;", false)
+	if len(list) != 3 || list[0] != "/* a; b */ select 1" || list[1] != "create function f() returns int as $$ begin return 1; end; $$ language plpgsql" {
+		t.Fatal(list)
+	}
+	list = splitStatements("insert into a values ('it\\'s;'); select 1", true)
+	if len(list) != 2 || list[0] != "insert into a values ('it\\'s;')" {
+		t.Fatal(list)
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	SetFS(fstest.MapFS{
+		"db/0002_user.up.sql":   {Data: []byte("create table sys_user (id int);")},
+		"db/0002_user.down.sql": {Data: []byte("drop table sys_user;")},
+		"db/0001_init.up.sql":   {Data: []byte("create table sys_role (id int);")},
+		"db/readme.md":          {Data: []byte("")},
+	})
+	defer SetFS(nil)
+	list, err := New(nil, "sqlite3", &syaml.MigrateInfo{Dir: "db"}).Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Version != 1 || list[1].Name != "user" || list[1].Down == "" || list[0].Checksum == "" {
+		t.Fatal(list)
+	}
+}
+
+func TestMigrateSqlite(t *testing.T) {
+	SetFS(fstest.MapFS{
+		"db/0001_init.up.sql":   {Data: []byte("create table sys_role (id int); insert into sys_role values (1);")},
+		"db/0001_init.down.sql": {Data: []byte("drop table sys_role;")},
+		"db/0002_user.up.sql":   {Data: []byte("create table sys_user (id int, name text default 'a;b');")},
+		"db/0002_user.down.sql": {Data: []byte("drop table sys_user;")},
+	})
+	defer SetFS(nil)
+	url := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000"
+	config := &syaml.MigrateInfo{Dir: "db"}
+	// 多个实例同时执行时每个版本只执行一次
+	var wg sync.WaitGroup
+	counts := make([]int, 3)
+	errs := make([]error, 3)
+	for i := range counts {
+		db, err := sql.Open("sqlite3", url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counts[i], errs[i] = New(db, "sqlite3", config).Up(context.Background())
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil || counts[0]+counts[1]+counts[2] != 2 {
+		t.Fatal(counts, err)
+	}
+	db, err := sql.Open("sqlite3", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m := New(db, "sqlite3", config)
+	list, err := m.Status(context.Background())
+	if err != nil || len(list) != 2 || !list[0].Applied || !list[1].Applied {
+		t.Fatal(list, err)
+	}
+	if n, err := m.Down(context.Background(), 1); err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+	list, err = m.Status(context.Background())
+	if err != nil || !list[0].Applied || list[1].Applied {
+		t.Fatal(list, err)
+	}
+	if _, err := db.Exec("select * from sys_user"); err == nil {
+		t.Fatal("回滚未执行")
+	}
+	if n, err := m.Up(context.Background()); err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+}
+
+func TestUnlockOwnOnly(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "lock.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m := New(db, "sqlite3", &syaml.MigrateInfo{})
+	unlock, err := m.lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 锁过期后被其它实例重新获取，本实例释放时不能删除其它实例的锁
+	if _, err = db.Exec("update schema_migrations_lock set locked_at = 1 where id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	var count int
+	if err = db.QueryRow("select count(*) from schema_migrations_lock").Scan(&count); err != nil || count != 1 {
+		t.Fatal(count, err)
+	}
+}
@@ -0,0 +1,188 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+const (
+	// 默认批量提交大小，超过方言参数数限制时按限制分批
+	defaultChunkSize = 300
+)
+
+// 批量插入数据，objs 为结构体切片，按 chunkSize 分批生成多行 insert，返回影响行数
+func (m *session) InsertBatch(objs interface{}, chunkSize int) (int64, error) {
+	return m.InsertBatchContext(m.context(), objs, chunkSize)
+}
+
+// 批量插入数据（带上下文）
+func (m *session) InsertBatchContext(ctx context.Context, objs interface{}, chunkSize int) (int64, error) {
+	var total int64
+	err := withHooks(m, ctx, objs, BeforeInsertHook.BeforeInsert, AfterInsertHook.AfterInsert, func(m *session, ctx context.Context) error {
+		n, err := m.insertBatch(ctx, objs, chunkSize, nil)
+		total = n
+		return err
+	})
+	return total, err
+}
+
+// 插入或更新数据，obj 可为单个结构体或切片；conflict 为冲突判断列，为空时使用主键；调用插入钩子
+func (m *session) Upsert(obj interface{}, conflict ...string) (int64, error) {
+	return m.UpsertContext(m.context(), obj, conflict...)
+}
+
+// 插入或更新数据（带上下文）
+func (m *session) UpsertContext(ctx context.Context, obj interface{}, conflict ...string) (int64, error) {
+	rows, err := batchRows(obj)
+	if err != nil {
+		return 0, err
+	}
+	if len(conflict) == 0 {
+		info := sbuilder.GetField(rows[0], 0)
+		if info.PrimaryKey == "" {
+			return 0, errors.New("插入或更新语句冲突列为空")
+		}
+		conflict = []string{info.PrimaryKey}
+	}
+	var total int64
+	err = withHooks(m, ctx, rows, BeforeInsertHook.BeforeInsert, AfterInsertHook.AfterInsert, func(m *session, ctx context.Context) error {
+		n, err := m.insertBatch(ctx, rows, defaultChunkSize, conflict)
+		total = n
+		return err
+	})
+	return total, err
+}
+
+// 同一表、相同非空列的待插入行
+type batchGroup struct {
+	table   string
+	tenant  string
+	columns []string
+	values  []interface{}
+	count   int
+}
+
+// 分批执行插入，conflict 不为空时生成插入或更新语句；
+// 非空列不同的行按列分组后分别批量插入，每批不超过 chunkSize 及方言的参数数、行数限制
+func (m *session) insertBatch(ctx context.Context, objs interface{}, chunkSize int, conflict []string) (int64, error) {
+	rows, err := batchRows(objs)
+	if err != nil {
+		return 0, err
+	}
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	maxParams, maxRows := sbuilder.BatchLimit(m.dialect)
+	if maxRows > 0 && chunkSize > maxRows {
+		chunkSize = maxRows
+	}
+	var total int64
+	flush := func(g *batchGroup) error {
+		if g.count == 0 {
+			return nil
+		}
+		var sql string
+		switch {
+		case len(conflict) == 0:
+			sql = m.dialect.Insert(g.table, g.columns, g.count)
+		case g.tenant != "":
+			// 多租户：不更新租户列，冲突记录属于其它租户时不更新
+			var err error
+			sql, err = sbuilder.TenantUpsert(m.dialect, g.table, g.columns, g.count, conflict, updateColumns(g.columns, conflict), g.tenant)
+			if err != nil {
+				return err
+			}
+		default:
+			sql = m.dialect.Upsert(g.table, g.columns, g.count, conflict, updateColumns(g.columns, conflict))
+		}
+		ret, err := m.exec(ctx, sql, g.values...)
+		n, err := getRowsAffected(ret, err)
+		if err != nil {
+			return err
+		}
+		total += n
+		g.values = make([]interface{}, 0)
+		g.count = 0
+		return nil
+	}
+	groups := make(map[string]*batchGroup)
+	order := make([]*batchGroup, 0)
+	for _, row := range rows {
+		info := m.getField(row, 1)
+		if err := m.infoError(info); err != nil {
+			return total, err
+		}
+		cols, vals := info.GetDbValues(sbuilder.EXEC)
+		key := info.TableName + ":" + strings.Join(cols, ",")
+		g, ok := groups[key]
+		if !ok {
+			g = &batchGroup{table: info.TableName, columns: cols, values: make([]interface{}, 0)}
+			if !m.ignoreTenant {
+				g.tenant = info.TenantColumn
+			}
+			groups[key] = g
+			order = append(order, g)
+		}
+		if g.count == chunkSize || (maxParams > 0 && g.count > 0 && len(g.values)+len(vals) > maxParams) {
+			if err := flush(g); err != nil {
+				return total, err
+			}
+		}
+		g.values = append(g.values, vals...)
+		g.count++
+	}
+	for _, g := range order {
+		if err := flush(g); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// 将切片（或单个结构体）展开为可设置字段的结构体指针
+func batchRows(objs interface{}) ([]interface{}, error) {
+	value := reflect.ValueOf(objs)
+	for value.Kind() == reflect.Ptr && value.Elem().Kind() == reflect.Slice {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return []interface{}{objs}, nil
+	}
+	if value.Len() == 0 {
+		return nil, errors.New("批量插入数据为空")
+	}
+	rows := make([]interface{}, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		item := value.Index(i)
+		if item.Kind() == reflect.Interface {
+			item = item.Elem()
+		}
+		if item.Kind() != reflect.Ptr && item.CanAddr() {
+			item = item.Addr()
+		}
+		rows = append(rows, item.Interface())
+	}
+	return rows, nil
+}
+
+// 冲突时需要更新的列（排除冲突判断列）
+func updateColumns(columns []string, conflict []string) []string {
+	result := make([]string, 0, len(columns))
+	for _, column := range columns {
+		exclude := false
+		for _, c := range conflict {
+			if strings.EqualFold(c, column) {
+				exclude = true
+				break
+			}
+		}
+		if !exclude {
+			result = append(result, column)
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+// 统计执行的SQL条数
+type countInterceptor struct {
+	count int
+}
+
+func (m *countInterceptor) Before(ctx context.Context, stmt *Statement) error {
+	return nil
+}
+
+func (m *countInterceptor) After(ctx context.Context, stmt *Statement) {
+	m.count++
+}
+
+// 限制参数数的方言
+type limitDialect struct {
+	sbuilder.Dialect
+}
+
+func (limitDialect) BatchLimit() (int, int) {
+	return 4, 0
+}
+
+func TestInsertBatch(t *testing.T) {
+	db := newTestDB(t)
+	counter := new(countInterceptor)
+	db.Use(counter)
+	rows := []testRole{{Id: "3", Name: "c"}, {Id: "4"}, {Id: "5", Name: "e"}, {Id: "6"}}
+	if n, err := db.InsertBatch(rows, 0); err != nil || n != 4 {
+		t.Fatal(n, err)
+	}
+	if counter.count != 2 {
+		t.Fatal("非空列不同的行未分组插入", counter.count)
+	}
+	counter.count = 0
+	db.dialect = limitDialect{db.dialect}
+	rows = []testRole{{Id: "7", Name: "g"}, {Id: "8", Name: "h"}, {Id: "9", Name: "i"}}
+	if n, err := db.InsertBatch(rows, 0); err != nil || n != 3 {
+		t.Fatal(n, err)
+	}
+	if counter.count != 2 {
+		t.Fatal("未按参数数限制分批", counter.count)
+	}
+}
@@ -0,0 +1,145 @@
+package sorm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/androidsr/sc-go/model"
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+// 游标分页默认每页条数
+const defaultCursorSize = 10
+
+// 游标分页：按排序列取上一页最后一行之后的数据，不查询总条数；多取一行判断是否有下一页
+func (m *session) cursorPage(ctx context.Context, data interface{}, page model.PageInfo, sql string, values ...interface{}) (*model.PageResult, error) {
+	size := page.Size
+	if size <= 0 {
+		size = defaultCursorSize
+	}
+	result := &model.PageResult{Current: page.Current, Size: size}
+	elem := elemType(data)
+	if elem == nil {
+		return nil, errors.New("游标分页结果需为结构体切片指针")
+	}
+	orders := sbuilder.ResolveOrders(page.Orders, sbuilder.OrderColumnsOf(data).Allow(m.orders...))
+	// 追加主键保证排序唯一
+	if pk := sbuilder.GetField(reflect.New(elem).Interface(), 0).PrimaryKey; pk != "" && !hasOrder(orders, pk) {
+		orders = append(orders, model.OrderItem{Column: pk, Asc: true})
+	}
+	if len(orders) == 0 {
+		return nil, errors.New("游标分页排序列为空")
+	}
+	types, err := orderTypes(elem, orders)
+	if err != nil {
+		return nil, err
+	}
+	sql = fmt.Sprintf("select * from (%s) t", sql)
+	if page.Cursor != "" {
+		cursor, err := decodeCursor(page.Cursor, types)
+		if err != nil {
+			return nil, err
+		}
+		where, vs := sbuilder.Keyset(orders, cursor).Build(m.dialect)
+		sql += " where " + where
+		values = append(values, vs...)
+	}
+	var pageValues []interface{}
+	sql, pageValues = m.dialect.Page(sql, sbuilder.OrderByClause(m.dialect, orders), size+1, 0)
+	values = append(values, pageValues...)
+	if err := m.list(ctx, data, sql, values...); err != nil {
+		log.Printf("执行SQL异常: %v\n", err)
+		return nil, err
+	}
+	rows := reflect.ValueOf(data).Elem()
+	if int64(rows.Len()) > size {
+		rows.Set(rows.Slice(0, int(size)))
+		if result.NextCursor, err = encodeCursor(rows.Index(int(size)-1), orders); err != nil {
+			return nil, err
+		}
+	}
+	result.Rows = data
+	return result, nil
+}
+
+// 结果切片的元素结构体类型
+func elemType(data interface{}) reflect.Type {
+	t := reflect.TypeOf(data)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
+		return nil
+	}
+	t = t.Elem().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+func hasOrder(orders []model.OrderItem, column string) bool {
+	for _, v := range orders {
+		if v.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// 排序列对应的字段类型，用于还原游标值
+func orderTypes(elem reflect.Type, orders []model.OrderItem) ([]reflect.Type, error) {
+	obj := reflect.New(elem).Interface()
+	types := make([]reflect.Type, 0, len(orders))
+	for _, v := range orders {
+		field, ok := sbuilder.FieldByColumn(obj, v.Column)
+		if !ok {
+			return nil, errors.New("游标分页排序列不在结果结构体中: " + v.Column)
+		}
+		types = append(types, field.Type())
+	}
+	return types, nil
+}
+
+// 按最后一行的排序列值生成游标
+func encodeCursor(row reflect.Value, orders []model.OrderItem) (string, error) {
+	for row.Kind() == reflect.Ptr {
+		row = row.Elem()
+	}
+	values := make([]interface{}, 0, len(orders))
+	for _, v := range orders {
+		field, _ := sbuilder.FieldByColumn(row.Interface(), v.Column)
+		values = append(values, field.Interface())
+	}
+	bs, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bs), nil
+}
+
+// 解析游标，按字段类型还原排序列值
+func decodeCursor(cursor string, types []reflect.Type) ([]interface{}, error) {
+	bs, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("游标无效")
+	}
+	raws := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(bs, &raws); err != nil || len(raws) != len(types) {
+		return nil, errors.New("游标无效")
+	}
+	values := make([]interface{}, 0, len(types))
+	for i, raw := range raws {
+		v := reflect.New(types[i])
+		if err := json.Unmarshal(raw, v.Interface()); err != nil {
+			return nil, errors.New("游标无效")
+		}
+		values = append(values, v.Elem().Interface())
+	}
+	return values, nil
+}
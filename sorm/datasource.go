@@ -0,0 +1,44 @@
+package sorm
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/androidsr/sc-go/syaml"
+)
+
+var (
+	// 命名数据源，New 时按 sqlx.datasources 配置注册，默认数据源名称为空
+	dataSources = make(map[string]*Sorm)
+	dsLock      sync.RWMutex
+)
+
+// 注册命名数据源
+func Register(name string, db *Sorm) {
+	dsLock.Lock()
+	defer dsLock.Unlock()
+	dataSources[name] = db
+}
+
+// 获取命名数据源，name 为空时返回 New 创建的默认数据源
+func Use(name string) (*Sorm, error) {
+	dsLock.RLock()
+	defer dsLock.RUnlock()
+	db, ok := dataSources[name]
+	if !ok || db == nil {
+		return nil, fmt.Errorf("数据源不存在:%s", name)
+	}
+	return db, nil
+}
+
+// 逻辑删除、表前缀、模板、多租户、数据权限及字段加密为全局设置，沿用默认数据源配置，命名数据源不可单独配置
+func checkDatasource(name string, config *syaml.SqlxInfo) error {
+	if config == nil {
+		return fmt.Errorf("数据源配置为空:%s", name)
+	}
+	if config.LogicDeleted != "" || config.LogicActive != "" || config.TablePrefix != "" || config.Templates != "" ||
+		config.Tenant != nil || config.DataScope != nil || config.Encrypt != nil || len(config.Datasources) > 0 {
+		return fmt.Errorf("数据源 %s 不支持单独配置逻辑删除、表前缀、模板、多租户、数据权限、字段加密及命名数据源", name)
+	}
+	return nil
+}
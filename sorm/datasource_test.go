@@ -0,0 +1,57 @@
+package sorm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/androidsr/sc-go/syaml"
+)
+
+func TestDatasource(t *testing.T) {
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+	for _, url := range []string{primary, replica} {
+		db := New(&syaml.SqlxInfo{Driver: "sqlite3", Url: url, LogLevel: "silent"})
+		if db == nil {
+			t.Fatal("创建数据库失败")
+		}
+		db.MustExec("create table test_role (id text primary key, name text)")
+		db.MustExec("insert into test_role (id, name) values ('1', ?)", filepath.Base(url))
+		db.Close()
+	}
+	config := &syaml.SqlxInfo{Driver: "sqlite3", Url: primary, LogLevel: "silent", Datasources: map[string]*syaml.SqlxInfo{
+		"report": {Driver: "sqlite3", Url: primary, Replicas: []string{replica}, LogLevel: "silent"},
+	}}
+	db := New(config)
+	if db == nil {
+		t.Fatal("创建数据库失败")
+	}
+	if ds, err := Use(""); err != nil || ds != db {
+		t.Fatal("默认数据源不正确", err)
+	}
+	if _, err := Use("none"); err == nil {
+		t.Fatal("数据源不存在未报错")
+	}
+	report, err := Use("report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := func(db *Sorm) string {
+		data := new(testRole)
+		if err := db.SelectOne(data, &testRole{Id: "1"}); err != nil {
+			t.Fatal(err)
+		}
+		return data.Name
+	}
+	if v := name(report); v != "replica.db" {
+		t.Fatal("查询未使用只读副本", v)
+	}
+	if v := name(report.ForcePrimary()); v != "primary.db" {
+		t.Fatal("强制主库未生效", v)
+	}
+
+	config.Datasources["report"].Tenant = &syaml.TenantInfo{}
+	if New(config) != nil {
+		t.Fatal("命名数据源单独配置多租户未报错")
+	}
+}
@@ -0,0 +1,153 @@
+package sorm
+
+import (
+	"context"
+	"log"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 实体生命周期钩子：实体实现对应方法后，插入、更新、删除时与SQL在同一事务中调用，返回错误时回滚并终止操作
+// 钩子中可通过 TxFromContext(ctx) 获取当前事务执行其它数据操作
+type BeforeInsertHook interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+type AfterInsertHook interface {
+	AfterInsert(ctx context.Context) error
+}
+
+type BeforeUpdateHook interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+type AfterUpdateHook interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+type BeforeDeleteHook interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+type AfterDeleteHook interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// 查询结果钩子：查询到数据后对每条记录调用，返回错误时查询返回该错误
+type AfterFindHook interface {
+	AfterFind(ctx context.Context) error
+}
+
+type txKey struct{}
+
+// 钩子上下文中的当前事务，不在事务中时返回nil
+func TxFromContext(ctx context.Context) *SormTx {
+	tx, _ := ctx.Value(txKey{}).(*SormTx)
+	return tx
+}
+
+// 在事务中执行带钩子的操作：已在事务中时直接使用当前事务，否则开启新事务
+func (m *session) hookTx(ctx context.Context, fn func(s *session, ctx context.Context) error) error {
+	if _, ok := m.db.(*sqlx.Tx); ok {
+		return fn(m, m.hookContext(ctx))
+	}
+	db, ok := m.db.(*sqlx.DB)
+	if !ok {
+		return fn(m, ctx)
+	}
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("开启事务失败: %v", err)
+		return err
+	}
+	stx := &SormTx{Tx: tx, session: m.with(tx)}
+	return stx.run(func(tx *SormTx) error {
+		return fn(tx.session, context.WithValue(ctx, txKey{}, tx))
+	}, tx.Commit, tx.Rollback)
+}
+
+// 事务中调用钩子的上下文：上下文中没有当前事务时加入
+func (m *session) hookContext(ctx context.Context) context.Context {
+	tx, ok := m.db.(*sqlx.Tx)
+	if !ok {
+		return ctx
+	}
+	if current := TxFromContext(ctx); current != nil && current.Tx == tx {
+		return ctx
+	}
+	return context.WithValue(ctx, txKey{}, &SormTx{Tx: tx, session: m})
+}
+
+// 对象（或切片中的每个元素）实现的钩子
+func hooksOf[T any](objs interface{}) []T {
+	if h, ok := objs.(T); ok {
+		return []T{h}
+	}
+	value := reflect.ValueOf(objs)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil
+	}
+	// 元素类型未实现钩子时不逐个判断
+	hook, elem := reflect.TypeOf((*T)(nil)).Elem(), value.Type().Elem()
+	if elem.Kind() != reflect.Interface && !elem.Implements(hook) && !reflect.PointerTo(elem).Implements(hook) {
+		return nil
+	}
+	var result []T
+	for i := 0; i < value.Len(); i++ {
+		item := value.Index(i)
+		if item.Kind() == reflect.Interface {
+			item = item.Elem()
+		}
+		if item.Kind() != reflect.Ptr && item.CanAddr() {
+			item = item.Addr()
+		}
+		if h, ok := item.Interface().(T); ok {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+// 带钩子执行操作：对象实现 before、after 对应钩子时在事务中依次调用，否则直接执行
+func withHooks[B, A any](m *session, ctx context.Context, obj interface{}, before func(B, context.Context) error, after func(A, context.Context) error,
+	fn func(s *session, ctx context.Context) error) error {
+	bs, as := hooksOf[B](obj), hooksOf[A](obj)
+	if len(bs) == 0 && len(as) == 0 {
+		return fn(m, ctx)
+	}
+	return m.hookTx(ctx, func(s *session, ctx context.Context) error {
+		for _, h := range bs {
+			if err := before(h, ctx); err != nil {
+				return err
+			}
+		}
+		if err := fn(s, ctx); err != nil {
+			return err
+		}
+		for _, h := range as {
+			if err := after(h, ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// 查询到数据后调用 AfterFind
+func (m *session) afterFind(ctx context.Context, data interface{}) error {
+	hooks := hooksOf[AfterFindHook](data)
+	if len(hooks) == 0 {
+		return nil
+	}
+	ctx = m.hookContext(ctx)
+	for _, h := range hooks {
+		if err := h.AfterFind(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type testHookRole struct {
+	Id   string `db:"id,primary_key"`
+	Name string `db:"name"`
+}
+
+func (testHookRole) TableName() string {
+	return "test_role"
+}
+
+func (m *testHookRole) BeforeInsert(ctx context.Context) error {
+	if m.Name == "" {
+		return errors.New("名称不能为空")
+	}
+	return nil
+}
+
+func (m *testHookRole) BeforeDelete(ctx context.Context) error {
+	if m.Id == "1" {
+		return errors.New("内置角色不能删除")
+	}
+	return nil
+}
+
+type testHookUser struct {
+	Id    string `db:"id,primary_key"`
+	Name  string `db:"name"`
+	depth int
+}
+
+func (testHookUser) TableName() string {
+	return "test_user"
+}
+
+func (m *testHookUser) AfterInsert(ctx context.Context) error {
+	tx := TxFromContext(ctx)
+	m.depth = tx.depth
+	// 钩子中的嵌套事务使用新的保存点
+	return tx.Transaction(ctx, func(tx *SormTx) error {
+		return tx.Insert(&testRole{Id: "3", Name: "c"})
+	})
+}
+
+func TestHookNestedTx(t *testing.T) {
+	db := newTestDB(t)
+	user := &testHookUser{Id: "3", Name: "c"}
+	err := db.Transaction(context.Background(), func(tx *SormTx) error {
+		return tx.Transaction(context.Background(), func(tx *SormTx) error {
+			return tx.InsertContext(context.Background(), user)
+		})
+	})
+	if err != nil || user.depth != 1 {
+		t.Fatal(user.depth, err)
+	}
+	if n := db.SelectCount("select * from test_role"); n != 3 {
+		t.Fatal(n)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := db.Upsert(&testHookRole{Id: "3"}); err == nil {
+		t.Fatal("Upsert 未调用插入钩子")
+	}
+	repo := NewRepo[testHookRole](db)
+	if err := repo.DeleteById("1"); err == nil {
+		t.Fatal("DeleteById 未调用删除钩子")
+	}
+	if err := repo.DeleteById("2"); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.SelectCount("select * from test_role"); n != 1 {
+		t.Fatal(n)
+	}
+}
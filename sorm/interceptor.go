@@ -0,0 +1,363 @@
+package sorm
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// 不输出SQL日志
+	LogSilent LogLevel = iota
+	// 只输出执行失败的SQL
+	LogError
+	// 输出执行失败及慢SQL
+	LogWarn
+	// 输出全部SQL
+	LogInfo
+)
+
+// 脱敏后的参数值
+const redactMask = "******"
+
+// 默认屏蔽的敏感列名关键字
+var sensitiveKeywords = []string{"password", "passwd", "pwd", "secret", "token", "salt"}
+
+type LogLevel int
+
+// 解析日志级别：silent、error、warn、info，为空时为info
+func ParseLogLevel(level string) LogLevel {
+	switch strings.ToLower(level) {
+	case "silent", "off", "none":
+		return LogSilent
+	case "error":
+		return LogError
+	case "warn":
+		return LogWarn
+	default:
+		return LogInfo
+	}
+}
+
+// SQL执行信息
+type Statement struct {
+	SQL      string
+	Args     []interface{}
+	Duration time.Duration
+	// 影响行数（查询时为返回行数）
+	Rows int64
+	Err  error
+}
+
+// SQL拦截器：Before 返回错误时不再执行SQL；After 在执行完成后调用（包括执行失败）
+type Interceptor interface {
+	Before(ctx context.Context, stmt *Statement) error
+	After(ctx context.Context, stmt *Statement)
+}
+
+// 拦截器列表：同一数据源派生的会话共用，增加时复制后替换，执行中的SQL使用原列表
+type interceptorList struct {
+	lock sync.RWMutex
+	list []Interceptor
+}
+
+func (m *interceptorList) load() []Interceptor {
+	if m == nil {
+		return nil
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.list
+}
+
+// 增加SQL拦截器，按添加顺序调用
+func (m *session) Use(interceptors ...Interceptor) {
+	if m.interceptors == nil {
+		m.interceptors = new(interceptorList)
+	}
+	m.interceptors.lock.Lock()
+	defer m.interceptors.lock.Unlock()
+	list := make([]Interceptor, 0, len(m.interceptors.list)+len(interceptors))
+	list = append(list, m.interceptors.list...)
+	m.interceptors.list = append(list, interceptors...)
+}
+
+// 执行SQL并调用拦截器，call 返回影响行数
+func (m *session) intercept(ctx context.Context, sql string, args []interface{}, call func(ctx context.Context) (int64, error)) error {
+	return m.interceptPaused(ctx, sql, args, nil, call)
+}
+
+// 执行SQL并调用拦截器，paused 为 call 中不计入执行时间的耗时（如逐行查询的回调）
+func (m *session) interceptPaused(ctx context.Context, sql string, args []interface{}, paused *time.Duration, call func(ctx context.Context) (int64, error)) error {
+	stmt := &Statement{SQL: sql, Args: args}
+	interceptors := m.interceptors.load()
+	for _, v := range interceptors {
+		if err := v.Before(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	ctx, cancel := m.timeout(ctx)
+	defer cancel()
+	start := time.Now()
+	stmt.Rows, stmt.Err = call(ctx)
+	stmt.Duration = time.Since(start)
+	if paused != nil {
+		stmt.Duration -= *paused
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptors[i].After(ctx, stmt)
+	}
+	return stmt.Err
+}
+
+// 按配置创建默认拦截器：默认屏蔽敏感列（见 DefaultRedactor），silent 级别不输出慢SQL
+func defaultInterceptors(level string, slow int, redact []string) *interceptorList {
+	redactor := DefaultRedactor(redact...)
+	logLevel := ParseLogLevel(level)
+	result := []Interceptor{&LogInterceptor{Level: logLevel, Redactor: redactor}}
+	if slow > 0 && logLevel != LogSilent {
+		result = append(result, &SlowInterceptor{Threshold: time.Duration(slow) * time.Millisecond, Redactor: redactor})
+	}
+	return &interceptorList{list: result}
+}
+
+// 日志拦截器：按级别输出SQL
+type LogInterceptor struct {
+	Level    LogLevel
+	Redactor *Redactor
+}
+
+func (m *LogInterceptor) Before(ctx context.Context, stmt *Statement) error {
+	return nil
+}
+
+func (m *LogInterceptor) After(ctx context.Context, stmt *Statement) {
+	if stmt.Err != nil {
+		if m.Level >= LogError {
+			log.Printf("执行SQL失败: %s\n%v\n%v", stmt.SQL, m.Redactor.Args(stmt.SQL, stmt.Args), stmt.Err)
+		}
+		return
+	}
+	if m.Level >= LogInfo {
+		log.Printf("执行SQL: %s\n%v\n耗时: %v 行数: %d", stmt.SQL, m.Redactor.Args(stmt.SQL, stmt.Args), stmt.Duration, stmt.Rows)
+	}
+}
+
+// 慢SQL拦截器：执行时间超过阈值时输出
+type SlowInterceptor struct {
+	Threshold time.Duration
+	Redactor  *Redactor
+}
+
+func (m *SlowInterceptor) Before(ctx context.Context, stmt *Statement) error {
+	return nil
+}
+
+func (m *SlowInterceptor) After(ctx context.Context, stmt *Statement) {
+	if m.Threshold > 0 && stmt.Duration >= m.Threshold {
+		log.Printf("慢SQL(%v): %s\n%v", stmt.Duration, stmt.SQL, m.Redactor.Args(stmt.SQL, stmt.Args))
+	}
+}
+
+// 参数脱敏：按列名屏蔽SQL参数，仅用于日志输出，不影响实际执行的参数
+type Redactor struct {
+	columns map[string]bool
+	// 列名包含其中任一关键字时屏蔽
+	keywords []string
+}
+
+// 创建参数脱敏器，columns 为需要屏蔽的列名（不区分大小写）
+func NewRedactor(columns ...string) *Redactor {
+	m := &Redactor{columns: make(map[string]bool, len(columns))}
+	for _, v := range columns {
+		m.columns[strings.ToLower(v)] = true
+	}
+	return m
+}
+
+// 创建默认参数脱敏器：除 columns 外，列名包含 password、passwd、pwd、secret、token、salt 的参数同样屏蔽
+func DefaultRedactor(columns ...string) *Redactor {
+	m := NewRedactor(columns...)
+	m.keywords = sensitiveKeywords
+	return m
+}
+
+// 返回脱敏后的参数
+func (m *Redactor) Args(sql string, args []interface{}) []interface{} {
+	if m == nil || (len(m.columns) == 0 && len(m.keywords) == 0) || len(args) == 0 {
+		return args
+	}
+	columns := argColumns(sql, len(args))
+	// 无法对应到列的参数，若语句涉及敏感列则一并脱敏
+	unknown := m.mentions(sql)
+	result := make([]interface{}, len(args))
+	for i, v := range args {
+		if m.sensitive(columns[i]) || columns[i] == "" && unknown {
+			result[i] = redactMask
+		} else {
+			result[i] = v
+		}
+	}
+	return result
+}
+
+// SQL中是否出现敏感列
+func (m *Redactor) mentions(sql string) bool {
+	for _, t := range sqlTokens(sql) {
+		if isIdent(t) && m.sensitive(identName(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Redactor) sensitive(column string) bool {
+	if column == "" {
+		return false
+	}
+	if m.columns[column] {
+		return true
+	}
+	for _, v := range m.keywords {
+		if strings.Contains(column, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// 推断每个 ? 占位符对应的列名：
+// insert 语句按列顺序对应，其余语句取占位符前最近一个比较运算符左侧的列
+func argColumns(sql string, n int) []string {
+	result := make([]string, n)
+	tokens := sqlTokens(sql)
+	var insertCols []string
+	inValues := false
+	if len(tokens) > 0 && strings.EqualFold(tokens[0], "insert") {
+		insertCols = insertColumns(tokens)
+	} else if len(tokens) > 0 && strings.EqualFold(tokens[0], "merge") {
+		// Oracle/SQL Server的upsert，参数均在using子句中，按insert列顺序循环
+		insertCols = mergeColumns(tokens)
+		inValues = len(insertCols) > 0
+	}
+	column := ""
+	idx := 0
+	for i, t := range tokens {
+		if idx >= n {
+			break
+		}
+		lower := strings.ToLower(t)
+		switch {
+		case t == "?":
+			if inValues && len(insertCols) > 0 {
+				result[idx] = insertCols[idx%len(insertCols)]
+			} else {
+				result[idx] = column
+			}
+			idx++
+		case lower == "values" || lower == "select" && insertCols != nil:
+			inValues = true
+		case lower == "limit" || lower == "offset" || lower == "fetch":
+			column = ""
+		case isIdent(t) && i+1 < len(tokens) && isOperator(tokens[i+1]):
+			column = identName(t)
+		}
+	}
+	return result
+}
+
+// merge ... when not matched then insert (a, b) 中的列名
+func mergeColumns(tokens []string) []string {
+	for i, t := range tokens {
+		if strings.EqualFold(t, "insert") {
+			return insertColumns(tokens[i+1:])
+		}
+	}
+	return nil
+}
+
+// insert into t(a, b) 中的列名
+func insertColumns(tokens []string) []string {
+	result := make([]string, 0)
+	start := -1
+	for i, t := range tokens {
+		if t == "(" {
+			start = i
+			break
+		}
+		if strings.EqualFold(t, "values") {
+			return result
+		}
+	}
+	for i := start + 1; start >= 0 && i < len(tokens) && tokens[i] != ")"; i++ {
+		if tokens[i] != "," {
+			result = append(result, identName(tokens[i]))
+		}
+	}
+	return result
+}
+
+// 简单SQL分词：标识符、字符串、运算符及单个符号
+func sqlTokens(sql string) []string {
+	tokens := make([]string, 0)
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'':
+			j := i + 1
+			for j < len(runes) && runes[j] != '\'' {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:min(j+1, len(runes))]))
+			i = j + 1
+		case strings.ContainsRune("=<>!", c):
+			j := i
+			for j < len(runes) && strings.ContainsRune("=<>!", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case strings.ContainsRune("(),?+|;*", c):
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("=<>!(),?+|;*'", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens
+}
+
+func isOperator(t string) bool {
+	switch strings.ToLower(t) {
+	case "=", "<>", "!=", "<", ">", "<=", ">=", "like", "not", "in", "between", "is":
+		return true
+	}
+	return false
+}
+
+func isIdent(t string) bool {
+	if t == "" || strings.HasPrefix(t, "'") || isOperator(t) {
+		return false
+	}
+	c := []rune(t)[0]
+	return unicode.IsLetter(c) || c == '_' || c == '`' || c == '"' || c == '['
+}
+
+// 去掉表别名及引号：`u`.`mail` -> mail
+func identName(t string) string {
+	if i := strings.LastIndex(t, "."); i >= 0 {
+		t = t[i+1:]
+	}
+	return strings.ToLower(strings.Trim(t, "`\"[]"))
+}
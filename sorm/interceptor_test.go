@@ -0,0 +1,59 @@
+package sorm
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+func TestDefaultInterceptors(t *testing.T) {
+	args := DefaultRedactor("phone").Args("update t set login_password = ?, phone = ?, name = ? where id = ?", []interface{}{"p", "138", "a", "1"})
+	if args[0] != redactMask || args[1] != redactMask || args[2] != "a" || args[3] != "1" {
+		t.Fatal(args)
+	}
+	if list := defaultInterceptors("silent", 10, nil).load(); len(list) != 1 {
+		t.Fatal("silent 级别仍输出慢SQL", list)
+	}
+	if list := defaultInterceptors("warn", 10, nil).load(); len(list) != 2 {
+		t.Fatal(list)
+	}
+}
+
+func TestUseConcurrent(t *testing.T) {
+	db := newTestDB(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			db.Use(&LogInterceptor{Level: LogSilent})
+		}()
+		go func() {
+			defer wg.Done()
+			var list []testRole
+			if err := db.SelectList(&list, &testRole{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(db.interceptors.load()); n != 11 {
+		t.Fatal(n)
+	}
+}
+
+func TestRedactMergeUpsert(t *testing.T) {
+	columns := []string{"id", "password"}
+	for _, driver := range []string{"godror", "sqlserver"} {
+		sql := sbuilder.GetDialect(driver).Upsert("t", columns, 2, []string{"id"}, []string{"password"})
+		args := DefaultRedactor().Args(sql, []interface{}{"1", "p1", "2", "p2"})
+		if args[0] != "1" || args[1] != redactMask || args[2] != "2" || args[3] != redactMask {
+			t.Fatal(driver, args)
+		}
+	}
+	args := DefaultRedactor().Args("call reset_password(?, ?)", []interface{}{"1", "p"})
+	if args[0] != redactMask || args[1] != redactMask {
+		t.Fatal(args)
+	}
+}
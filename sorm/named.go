@@ -0,0 +1,63 @@
+package sorm
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/androidsr/sc-go/model"
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+var (
+	// SQL模板文件来源，未设置时从当前目录读取
+	templateFS fs.FS
+)
+
+// 设置SQL模板文件来源，通常为 embed.FS，需在 New 之前调用
+func SetTemplateFS(fsys fs.FS) {
+	templateFS = fsys
+}
+
+// 按配置加载SQL模板
+func loadTemplates(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	fsys := templateFS
+	if fsys == nil {
+		fsys = os.DirFS(".")
+	}
+	return sbuilder.LoadNamed(fsys, pattern)
+}
+
+// 按SQL模板查询集合，name 为 命名空间.ID，params 为结构体或 map
+func (m *session) SelectNamed(dest interface{}, name string, params interface{}) error {
+	return m.SelectNamedContext(m.context(), dest, name, params)
+}
+
+// 按SQL模板查询集合（带上下文）
+func (m *session) SelectNamedContext(ctx context.Context, dest interface{}, name string, params interface{}) error {
+	sql, values, err := sbuilder.Named(name, params)
+	if err != nil {
+		log.Printf("生成SQL异常:%v\n", err)
+		return err
+	}
+	return m.selectContext(ctx, dest, sql, values...)
+}
+
+// 按SQL模板分页查询数据
+func (m *session) SelectPageNamed(data interface{}, page model.PageInfo, name string, params interface{}) *model.PageResult {
+	return m.SelectPageNamedContext(m.context(), data, page, name, params)
+}
+
+// 按SQL模板分页查询数据（带上下文）
+func (m *session) SelectPageNamedContext(ctx context.Context, data interface{}, page model.PageInfo, name string, params interface{}) *model.PageResult {
+	sql, values, err := sbuilder.Named(name, params)
+	if err != nil {
+		log.Printf("生成SQL异常:%v\n", err)
+		return nil
+	}
+	return m.SelectPageContext(ctx, data, page, sql, values...)
+}
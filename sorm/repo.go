@@ -0,0 +1,228 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/androidsr/sc-go/model"
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+// 泛型数据操作对象：按 sbuilder tag 约定生成SQL，查询对象的非空字段作为条件
+type Repo[T any] struct {
+	s *session
+}
+
+// 创建泛型数据操作对象，db 为nil时使用默认 DB
+func NewRepo[T any](db *Sorm) *Repo[T] {
+	if db == nil {
+		db = DB
+	}
+	return &Repo[T]{s: db.session}
+}
+
+// 创建事务内的泛型数据操作对象
+func NewRepoTx[T any](tx *SormTx) *Repo[T] {
+	return &Repo[T]{s: tx.session}
+}
+
+// 查询对象结构信息，query 为nil时不带条件
+func (m *Repo[T]) info(query *T) *sbuilder.StructInfo {
+	if query != nil {
+		return m.s.getField(query, 0)
+	}
+	info := m.s.getField(new(T), 0)
+	info.Fields = nil
+	return info
+}
+
+// 主键条件结构信息
+func (m *Repo[T]) idInfo(id interface{}) (*sbuilder.StructInfo, error) {
+	info := m.info(nil)
+	if info.PrimaryKey == "" {
+		return nil, errors.New("实体未定义主键")
+	}
+	// 空主键会使条件被忽略，查询、删除变为全表操作
+	if isEmptyId(id) {
+		return nil, errors.New("主键值为空")
+	}
+	info.Fields = []sbuilder.FieldInfo{{TagDB: info.PrimaryKey, TagColumn: info.PrimaryKey, TagKeyword: sbuilder.Eq, Value: id}}
+	return info, nil
+}
+
+// 主键值是否为空：nil、空字符串及零值
+func isEmptyId(id interface{}) bool {
+	v := reflect.ValueOf(id)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	return !v.IsValid() || v.IsZero()
+}
+
+// 按主键查询
+func (m *Repo[T]) FindById(id interface{}) (*T, error) {
+	return m.FindByIdContext(m.s.context(), id)
+}
+
+// 按主键查询（带上下文）
+func (m *Repo[T]) FindByIdContext(ctx context.Context, id interface{}) (*T, error) {
+	info, err := m.idInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	sql, values, err := m.s.selectSQL(info, nil)
+	if err != nil {
+		return nil, err
+	}
+	data := new(T)
+	if err := m.s.get(ctx, data, sql, values...); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// 查询集合
+func (m *Repo[T]) List(query *T) ([]T, error) {
+	return m.ListContext(m.s.context(), query)
+}
+
+// 查询集合（带上下文）
+func (m *Repo[T]) ListContext(ctx context.Context, query *T) ([]T, error) {
+	sql, values, err := m.s.selectSQL(m.info(query), nil)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]T, 0)
+	if err := m.s.list(ctx, &data, sql, values...); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// 分页查询，Rows 为 []T
+func (m *Repo[T]) Page(query *T, page model.PageInfo) (*model.PageResult, error) {
+	return m.PageContext(m.s.context(), query, page)
+}
+
+// 分页查询（带上下文）
+func (m *Repo[T]) PageContext(ctx context.Context, query *T, page model.PageInfo) (*model.PageResult, error) {
+	return m.page(ctx, m.info(query), page)
+}
+
+// 按查询对象分页查询：query 可为查询DTO等其它结构体，表名取 T
+func (m *Repo[T]) PageBy(query interface{}, page model.PageInfo) (*model.PageResult, error) {
+	return m.PageByContext(m.s.context(), query, page)
+}
+
+// 按查询对象分页查询（带上下文）
+func (m *Repo[T]) PageByContext(ctx context.Context, query interface{}, page model.PageInfo) (*model.PageResult, error) {
+	info := m.info(nil)
+	if query != nil {
+		q := m.s.getField(query, 0)
+		info.Fields = q.Fields
+		// 查询DTO声明数据权限时以DTO为准
+		if q.Scope != nil {
+			info.Scope = q.Scope
+		}
+	}
+	return m.page(ctx, info, page)
+}
+
+func (m *Repo[T]) page(ctx context.Context, info *sbuilder.StructInfo, page model.PageInfo) (*model.PageResult, error) {
+	sql, values, err := m.s.selectSQL(info, nil)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]T, 0)
+	result, err := m.s.selectPage(ctx, &data, page, sql, values...)
+	if err != nil {
+		return nil, err
+	}
+	result.Rows = data
+	return result, nil
+}
+
+// 插入数据
+func (m *Repo[T]) Insert(obj *T) error {
+	return m.s.InsertContext(m.s.context(), obj)
+}
+
+// 插入数据（带上下文）
+func (m *Repo[T]) InsertContext(ctx context.Context, obj *T) error {
+	return m.s.InsertContext(ctx, obj)
+}
+
+// 按主键更新非空字段
+func (m *Repo[T]) UpdateById(obj *T) error {
+	return m.s.UpdateByIdContext(m.s.context(), obj)
+}
+
+// 按主键更新非空字段（带上下文）
+func (m *Repo[T]) UpdateByIdContext(ctx context.Context, obj *T) error {
+	return m.s.UpdateByIdContext(ctx, obj)
+}
+
+// 按主键删除
+func (m *Repo[T]) DeleteById(id interface{}) error {
+	return m.DeleteByIdContext(m.s.context(), id)
+}
+
+// 按主键删除（带上下文）
+func (m *Repo[T]) DeleteByIdContext(ctx context.Context, id interface{}) error {
+	info, err := m.idInfo(id)
+	if err != nil {
+		return err
+	}
+	// 钩子对象只有主键值
+	obj := new(T)
+	if field, ok := sbuilder.FieldByColumn(obj, info.PrimaryKey); ok {
+		setValue(field, id)
+	}
+	return withHooks(m.s, ctx, obj, BeforeDeleteHook.BeforeDelete, AfterDeleteHook.AfterDelete, func(s *session, ctx context.Context) error {
+		return s.delete(ctx, info)
+	})
+}
+
+// 设置字段值，类型不一致时按数字、字符串转换，无法转换时不设置
+func setValue(field reflect.Value, value interface{}) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr && !v.IsNil() && field.Kind() != reflect.Ptr {
+		v = v.Elem()
+	}
+	if !field.CanSet() || !v.IsValid() {
+		return
+	}
+	switch {
+	case v.Type().AssignableTo(field.Type()):
+		field.Set(v)
+	case v.CanInt() && (field.CanInt() || field.CanUint()), v.CanUint() && (field.CanInt() || field.CanUint()),
+		v.Kind() == reflect.String && field.Kind() == reflect.String:
+		field.Set(v.Convert(field.Type()))
+	}
+}
+
+// 按条件获取数据条数
+func (m *Repo[T]) Count(query *T) (int64, error) {
+	return m.CountContext(m.s.context(), query)
+}
+
+// 按条件获取数据条数（带上下文）
+func (m *Repo[T]) CountContext(ctx context.Context, query *T) (int64, error) {
+	count, err := m.s.getCount(ctx, m.info(query))
+	return int64(count), err
+}
+
+// 判断数据是否存在
+func (m *Repo[T]) Exists(query *T) (bool, error) {
+	return m.ExistsContext(m.s.context(), query)
+}
+
+// 判断数据是否存在（带上下文）
+func (m *Repo[T]) ExistsContext(ctx context.Context, query *T) (bool, error) {
+	count, err := m.CountContext(ctx, query)
+	return count > 0, err
+}
@@ -0,0 +1,866 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/androidsr/sc-go/model"
+	"github.com/androidsr/sc-go/sbuilder"
+	"github.com/androidsr/sc-go/sc"
+	"github.com/androidsr/sc-go/sgin"
+	"github.com/androidsr/sc-go/syaml"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// 乐观锁更新失败：数据已被其他操作修改
+	ErrOptimisticLock = errors.New("数据已被修改，请刷新后重试")
+	// 开启多租户但当前请求没有租户信息
+	ErrTenantMissing = errors.New("租户信息为空")
+)
+
+// 数据操作会话：Sorm 与 SormTx 共用同一套操作，区别仅在于执行SQL的db
+type session struct {
+	db           sqlx.ExtContext
+	config       *syaml.SqlxInfo
+	dialect      sbuilder.Dialect
+	ctx          context.Context
+	interceptors *interceptorList
+	// 为true时不处理逻辑删除：查询包含已删除数据，删除为物理删除
+	unscoped bool
+	// 为true时不处理多租户
+	ignoreTenant bool
+	// 为true时不处理数据权限
+	ignoreScope bool
+	// 插入、更新时忽略的列
+	omit []string
+	// 分页查询允许的排序列（结果结构体的列以外）
+	orders []string
+	// 只读副本，查询时轮询使用；事务中为空
+	replicas []*sqlx.DB
+	next     *uint32
+	// 为true时查询也使用主库
+	primary bool
+	// 事务嵌套层数，用于生成保存点名称
+	depth int
+}
+
+// 使用指定db创建新的会话
+func (m *session) with(db sqlx.ExtContext) *session {
+	s := &session{db: db, config: m.config, dialect: m.dialect, ctx: m.ctx, interceptors: m.interceptors, unscoped: m.unscoped, ignoreTenant: m.ignoreTenant, ignoreScope: m.ignoreScope,
+		omit: m.omit, orders: m.orders, replicas: m.replicas, next: m.next, primary: m.primary, depth: m.depth}
+	// 事务固定使用主库
+	if _, ok := db.(*sqlx.Tx); ok {
+		s.replicas = nil
+	}
+	return s
+}
+
+// 查询使用的db：未强制主库且有只读副本时轮询副本
+func (m *session) reader() sqlx.ExtContext {
+	if m.primary || len(m.replicas) == 0 {
+		return m.db
+	}
+	n := atomic.AddUint32(m.next, 1)
+	return m.replicas[n%uint32(len(m.replicas))]
+}
+
+// 解析对象字段：按会话设置逻辑删除条件，插入、更新时去掉忽略的列（主键除外）
+func (m *session) getField(obj interface{}, fillType int) *sbuilder.StructInfo {
+	info := sbuilder.GetField(obj, fillType)
+	m.inherit(info)
+	if info.TenantColumn != "" && !m.ignoreTenant && fillType != 0 {
+		fillTenant(obj, info, fillType)
+	}
+	if fillType != 0 {
+		m.omitFields(info)
+	}
+	return info
+}
+
+// 按会话设置逻辑删除、多租户及数据权限的处理方式
+func (m *session) inherit(info *sbuilder.StructInfo) {
+	info.Unscoped = m.unscoped
+	info.IgnoreTenant = m.ignoreTenant
+	info.IgnoreScope = m.ignoreScope
+}
+
+// 移除 Omit 指定的列，主键保留
+func (m *session) omitFields(info *sbuilder.StructInfo) {
+	if len(m.omit) == 0 {
+		return
+	}
+	fields := make([]sbuilder.FieldInfo, 0, len(info.Fields))
+	for _, v := range info.Fields {
+		if v.TagDB == info.PrimaryKey || !sc.Contains(m.omit, v.TagDB) {
+			fields = append(fields, v)
+		}
+	}
+	info.Fields = fields
+}
+
+// 多租户：插入时填充当前租户，更新时不更新租户列
+func fillTenant(obj interface{}, info *sbuilder.StructInfo, fillType int) {
+	fields := make([]sbuilder.FieldInfo, 0, len(info.Fields)+1)
+	for _, v := range info.Fields {
+		if v.TagDB != info.TenantColumn {
+			fields = append(fields, v)
+		}
+	}
+	if fillType == 1 && info.TenantValue != nil {
+		column := info.TenantColumn
+		fields = append(fields, sbuilder.FieldInfo{TagDB: column, TagColumn: column, TagKeyword: sbuilder.Eq, Value: info.TenantValue})
+		value := reflect.ValueOf(info.TenantValue)
+		if field, ok := sbuilder.FieldByColumn(obj, column); ok && field.CanSet() && value.Type().AssignableTo(field.Type()) {
+			field.Set(value)
+		}
+	}
+	info.Fields = fields
+}
+
+// 字段解析错误（如加密失败）；需处理多租户但当前没有租户时返回 ErrTenantMissing
+func (m *session) infoError(info *sbuilder.StructInfo) error {
+	if info.Err != nil {
+		return info.Err
+	}
+	if info.TenantColumn != "" && !m.ignoreTenant && info.TenantValue == nil {
+		return ErrTenantMissing
+	}
+	return nil
+}
+
+// 获取当前数据库方言
+func (m *session) Dialect() sbuilder.Dialect {
+	return m.dialect
+}
+
+// 未指定上下文时使用的默认上下文：事务上下文 > sgin请求上下文 > Background
+func (m *session) context() context.Context {
+	if m.ctx != nil {
+		return m.ctx
+	}
+	if c := sgin.GetContext(); c != nil && c.Request != nil {
+		return c.Request.Context()
+	}
+	return context.Background()
+}
+
+// 按配置设置单条SQL执行超时时间
+func (m *session) timeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.config == nil || m.config.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Duration(m.config.Timeout)*time.Millisecond)
+}
+
+func (m *session) exec(ctx context.Context, sql string, values ...interface{}) (ret sql.Result, err error) {
+	err = m.intercept(ctx, sql, values, func(ctx context.Context) (int64, error) {
+		ret, err = m.db.ExecContext(ctx, m.dialect.Rebind(sql), values...)
+		if err != nil {
+			return 0, err
+		}
+		rows, _ := ret.RowsAffected()
+		return rows, nil
+	})
+	return ret, err
+}
+
+func (m *session) get(ctx context.Context, data interface{}, sql string, values ...interface{}) error {
+	err := m.intercept(ctx, sql, values, func(ctx context.Context) (int64, error) {
+		err := sqlx.GetContext(ctx, m.reader(), data, m.dialect.Rebind(sql), values...)
+		if err != nil {
+			return 0, err
+		}
+		return 1, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := sbuilder.DecryptFields(data); err != nil {
+		return err
+	}
+	return m.afterFind(ctx, data)
+}
+
+func (m *session) list(ctx context.Context, data interface{}, sql string, values ...interface{}) error {
+	err := m.intercept(ctx, sql, values, func(ctx context.Context) (int64, error) {
+		err := sqlx.SelectContext(ctx, m.reader(), data, m.dialect.Rebind(sql), values...)
+		if err != nil {
+			return 0, err
+		}
+		return int64(reflect.Indirect(reflect.ValueOf(data)).Len()), nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := sbuilder.DecryptFields(data); err != nil {
+		return err
+	}
+	return m.afterFind(ctx, data)
+}
+
+// 判断数据是否存在
+func (m *session) Exists(obj interface{}) bool {
+	return m.ExistsContext(m.context(), obj)
+}
+
+// 判断数据是否存在（带上下文）
+func (m *session) ExistsContext(ctx context.Context, obj interface{}) bool {
+	count := m.GetCountContext(ctx, obj)
+	return count > 0
+}
+
+// 按条件获取数据条数
+func (m *session) GetCount(obj interface{}) int {
+	return m.GetCountContext(m.context(), obj)
+}
+
+// 按条件获取数据条数（带上下文）
+func (m *session) GetCountContext(ctx context.Context, obj interface{}) int {
+	count, _ := m.getCount(ctx, m.getField(obj, 0))
+	return count
+}
+
+func (m *session) getCount(ctx context.Context, info *sbuilder.StructInfo) (int, error) {
+	if info.Err != nil {
+		log.Printf("执行SQL异常:%v\n", info.Err)
+		return 0, info.Err
+	}
+	builder := sbuilder.BuildQueryDialect(info, m.dialect)
+	sql := fmt.Sprintf("select count(*) from %s where 1=1 %s", m.dialect.Quote(info.TableName), builder.Sql.String())
+	var count int
+	err := m.get(ctx, &count, sql, builder.Values...)
+	if err != nil {
+		log.Printf("执行SQL异常:%s\n %v", sql, err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// 数据总条数
+func (m *session) SelectCount(sql string, values ...interface{}) int {
+	return m.SelectCountContext(m.context(), sql, values...)
+}
+
+// 数据总条数（带上下文）
+func (m *session) SelectCountContext(ctx context.Context, sql string, values ...interface{}) int {
+	// 没有结果结构体，按查询结果的列判断是否包装租户条件
+	if column, ok := m.rawTenant(sql); ok {
+		has, err := m.hasColumn(ctx, sql, values, column)
+		if err == nil && has {
+			sql, values, err = m.wrapTenant(sql, values, column)
+		}
+		if err != nil {
+			log.Printf("执行SQL异常:%s\n %v", sql, err)
+			return 0
+		}
+	}
+	count, _ := m.selectCount(ctx, sql, values...)
+	return count
+}
+
+func (m *session) selectCount(ctx context.Context, sql string, values ...interface{}) (int, error) {
+	var count int
+	sql = fmt.Sprintf("select count(*) from (%s) t", sql)
+	err := m.get(ctx, &count, sql, values...)
+	if err != nil {
+		log.Printf("执行SQL异常:%s\n %v", sql, err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// 插入数据
+func (m *session) Insert(obj interface{}) error {
+	return m.InsertContext(m.context(), obj)
+}
+
+// 插入数据（带上下文）
+func (m *session) InsertContext(ctx context.Context, obj interface{}) error {
+	return withHooks(m, ctx, obj, BeforeInsertHook.BeforeInsert, AfterInsertHook.AfterInsert, func(m *session, ctx context.Context) error {
+		info := m.getField(obj, 1)
+		if err := m.infoError(info); err != nil {
+			return err
+		}
+		columns, values := info.GetDbValues(sbuilder.EXEC)
+		sql := m.dialect.Insert(info.TableName, columns, 1)
+		ret, err := m.exec(ctx, sql, values...)
+		return getAffectedRow(ret, err)
+	})
+}
+
+// 按ID更新非空字段
+func (m *session) UpdateById(obj interface{}) error {
+	return m.UpdateByIdContext(m.context(), obj)
+}
+
+// 按ID更新非空字段（带上下文）
+func (m *session) UpdateByIdContext(ctx context.Context, obj interface{}) error {
+	return withHooks(m, ctx, obj, BeforeUpdateHook.BeforeUpdate, AfterUpdateHook.AfterUpdate, func(m *session, ctx context.Context) error {
+		info := m.getField(obj, 2)
+		column, values := info.GetDbValues(sbuilder.EXEC)
+		sql, values, err := m.updateSQL(info, column, values, info.PrimaryKey)
+		if err != nil {
+			return err
+		}
+		ret, err := m.exec(ctx, sql, values...)
+		return checkVersion(obj, info, ret, err)
+	})
+}
+
+// 更新数据（指定条件列）
+func (m *session) Update(obj interface{}, condition ...string) error {
+	return m.UpdateContext(m.context(), obj, condition...)
+}
+
+// 更新数据（指定条件列，带上下文）
+func (m *session) UpdateContext(ctx context.Context, obj interface{}, condition ...string) error {
+	if len(condition) == 0 {
+		return errors.New("更新语句条件为空")
+	}
+	return withHooks(m, ctx, obj, BeforeUpdateHook.BeforeUpdate, AfterUpdateHook.AfterUpdate, func(m *session, ctx context.Context) error {
+		info := m.getField(obj, 2)
+		column, values := info.GetDbValues(sbuilder.EXEC)
+		for _, v := range condition {
+			if !sc.Contains(column, v) {
+				return errors.New("更新语句条件列值为空: " + v)
+			}
+		}
+		if err := encryptCondition(obj, column, values, condition); err != nil {
+			return err
+		}
+		sql, values, err := m.updateSQL(info, column, values, condition...)
+		if err != nil {
+			return err
+		}
+		ret, err := m.exec(ctx, sql, values...)
+		return checkVersion(obj, info, ret, err)
+	})
+}
+
+// 加密列作为更新条件时按查询方式重新加密（确定性加密，包含轮换前密钥的密文）
+func encryptCondition(obj interface{}, columns []string, values []interface{}, condition []string) error {
+	for i, column := range columns {
+		if !sc.Contains(condition, column) {
+			continue
+		}
+		field, ok := sbuilder.FieldByColumn(obj, column)
+		if !ok {
+			continue
+		}
+		value, encrypted, err := sbuilder.EncryptQuery(obj, column, field.Interface())
+		if err != nil {
+			return err
+		}
+		if encrypted {
+			values[i] = value
+		}
+	}
+	return nil
+}
+
+// 按主键更新指定列（数据库列名或字段名），零值、空值及nil指针同样更新
+func (m *session) UpdateColumns(obj interface{}, columns ...string) error {
+	return m.UpdateColumnsContext(m.context(), obj, columns...)
+}
+
+// 按主键更新指定列（带上下文）
+func (m *session) UpdateColumnsContext(ctx context.Context, obj interface{}, columns ...string) error {
+	if len(columns) == 0 {
+		return errors.New("更新列为空")
+	}
+	return withHooks(m, ctx, obj, BeforeUpdateHook.BeforeUpdate, AfterUpdateHook.AfterUpdate, func(m *session, ctx context.Context) error {
+		info, err := sbuilder.GetFieldColumns(obj, columns...)
+		if err != nil {
+			return err
+		}
+		m.inherit(info)
+		m.omitFields(info)
+		column, values := info.GetDbValues(sbuilder.EXEC)
+		if i := slices.Index(column, info.PrimaryKey); info.PrimaryKey == "" || i < 0 || values[i] == nil || values[i] == "" {
+			return errors.New("更新语句主键为空")
+		}
+		sql, values, err := m.updateSQL(info, column, values, info.PrimaryKey)
+		if err != nil {
+			return err
+		}
+		ret, err := m.exec(ctx, sql, values...)
+		return checkVersion(obj, info, ret, err)
+	})
+}
+
+// 生成更新语句，有版本号列时增加乐观锁条件并将版本号加1；没有更新列或条件列值为空时返回错误
+// 处理多租户时租户列不更新，由构建器追加当前租户条件
+func (m *session) updateSQL(info *sbuilder.StructInfo, columns []string, values []interface{}, condition ...string) (string, []interface{}, error) {
+	if err := m.infoError(info); err != nil {
+		return "", nil, err
+	}
+	// 版本号及租户条件不能代替条件列，否则会更新同版本的全部记录
+	if len(condition) == 0 {
+		return "", nil, errors.New("更新语句条件为空")
+	}
+	for _, v := range condition {
+		if i := slices.Index(columns, v); v == "" || i < 0 || isEmptyId(values[i]) {
+			return "", nil, errors.New("更新语句条件列值为空: " + v)
+		}
+	}
+	dialect := m.dialect
+	builder := sbuilder.Update(info.TableName).WithDialect(dialect)
+	if m.ignoreTenant {
+		builder.IgnoreTenant()
+	}
+	sets := 0
+	for i, column := range columns {
+		quoted := dialect.Quote(column)
+		if column == info.TenantColumn && !m.ignoreTenant {
+			continue
+		}
+		if column == info.Version {
+			builder.SetExpr(fmt.Sprintf("%s = %s + 1", quoted, quoted))
+			builder.Where(sbuilder.Col(quoted).Eq(values[i]))
+		} else if sc.Contains(condition, column) {
+			builder.Where(eqCondition(dialect, column, values[i]))
+		} else {
+			builder.Set(column, values[i])
+			sets++
+		}
+	}
+	if sets == 0 {
+		return "", nil, errors.New("更新语句更新列为空")
+	}
+	if !builder.HasWhere() {
+		return "", nil, errors.New("更新语句条件为空")
+	}
+	sql, values := builder.Build()
+	return sql, values, nil
+}
+
+// 乐观锁校验：带版本号更新且影响行数为0时返回 ErrOptimisticLock，更新成功后对象版本号加1
+func checkVersion(obj interface{}, info *sbuilder.StructInfo, ret sql.Result, err error) error {
+	rows, err := getRowsAffected(ret, err)
+	if err != nil || info.Version == "" {
+		return err
+	}
+	columns, _ := info.GetDbValues(sbuilder.EXEC)
+	if !sc.Contains(columns, info.Version) {
+		return nil
+	}
+	if rows == 0 {
+		return ErrOptimisticLock
+	}
+	field, ok := sbuilder.FieldByColumn(obj, info.Version)
+	if !ok || !field.CanSet() {
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(field.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(field.Uint() + 1)
+	}
+	return nil
+}
+
+// 删除数据（启用逻辑删除时更新删除标记）
+func (m *session) Delete(obj interface{}) error {
+	return m.DeleteContext(m.context(), obj)
+}
+
+// 删除数据（带上下文）
+func (m *session) DeleteContext(ctx context.Context, obj interface{}) error {
+	return withHooks(m, ctx, obj, BeforeDeleteHook.BeforeDelete, AfterDeleteHook.AfterDelete, func(m *session, ctx context.Context) error {
+		return m.delete(ctx, m.getField(obj, 0))
+	})
+}
+
+func (m *session) delete(ctx context.Context, info *sbuilder.StructInfo) error {
+	if err := m.infoError(info); err != nil {
+		return err
+	}
+	column, values := info.GetDbValues(sbuilder.EXEC)
+	var builder sbuilder.SqlBuilder
+	if info.LogicDelete != "" && !info.Unscoped {
+		b := logicDeleteBuilder(m.dialect, info, column, values)
+		if !b.HasWhere() {
+			return errors.New("删除语句条件为空")
+		}
+		// 只更新未删除的数据
+		b.Where(sbuilder.Col(m.dialect.Quote(info.LogicDelete)).Eq(info.ActiveValue))
+		if m.ignoreTenant {
+			b.IgnoreTenant()
+		}
+		builder = b
+	} else {
+		b := deleteBuilder(m.dialect, info.TableName, column, values)
+		if !b.HasWhere() {
+			return errors.New("删除语句条件为空")
+		}
+		if m.ignoreTenant {
+			b.IgnoreTenant()
+		}
+		builder = b
+	}
+	sql, values := builder.Build()
+	ret, err := m.exec(ctx, sql, values...)
+	return getAffectedRow(ret, err)
+}
+
+// 逻辑删除：将删除标记更新为已删除值，条件不含删除标记列
+func logicDeleteBuilder(dialect sbuilder.Dialect, info *sbuilder.StructInfo, columns []string, values []interface{}) *sbuilder.UpdateBuilder {
+	builder := sbuilder.Update(info.TableName).WithDialect(dialect).Set(info.LogicDelete, info.DeletedValue)
+	for i, column := range columns {
+		if column != info.LogicDelete {
+			builder.Where(eqCondition(dialect, column, values[i]))
+		}
+	}
+	return builder
+}
+
+func deleteBuilder(dialect sbuilder.Dialect, tableName string, columns []string, values []interface{}) *sbuilder.DeleteBuilder {
+	builder := sbuilder.DeleteFrom(tableName).WithDialect(dialect)
+	for i, column := range columns {
+		builder.Where(eqCondition(dialect, column, values[i]))
+	}
+	return builder
+}
+
+// 等值条件；加密列有多个密钥的密文时为 in 条件
+func eqCondition(dialect sbuilder.Dialect, column string, value interface{}) sbuilder.Condition {
+	if values, ok := value.([]interface{}); ok {
+		return sbuilder.Col(dialect.Quote(column)).In(values)
+	}
+	return sbuilder.Col(dialect.Quote(column)).Eq(value)
+}
+
+// 按条件批量更新，条件为空时返回错误，返回影响行数；不调用实体钩子，定义了钩子的实体需使用 Update 等实体操作
+func (m *session) UpdateWhere(builder *sbuilder.UpdateBuilder) (int64, error) {
+	return m.UpdateWhereContext(m.context(), builder)
+}
+
+// 按条件批量更新（带上下文）
+func (m *session) UpdateWhereContext(ctx context.Context, builder *sbuilder.UpdateBuilder) (int64, error) {
+	if !builder.HasWhere() {
+		return 0, errors.New("更新语句条件为空")
+	}
+	if m.ignoreTenant {
+		builder.IgnoreTenant()
+	}
+	if err := builder.Encrypt(); err != nil {
+		return 0, err
+	}
+	sql, values := builder.WithDialect(m.dialect).Build()
+	return getRowsAffected(m.exec(ctx, sql, values...))
+}
+
+// 按条件批量删除（物理删除），条件为空时返回错误，返回影响行数；不调用实体钩子，定义了钩子的实体需使用 Delete 等实体操作
+func (m *session) DeleteWhere(builder *sbuilder.DeleteBuilder) (int64, error) {
+	return m.DeleteWhereContext(m.context(), builder)
+}
+
+// 按条件批量删除（带上下文）
+func (m *session) DeleteWhereContext(ctx context.Context, builder *sbuilder.DeleteBuilder) (int64, error) {
+	if !builder.HasWhere() {
+		return 0, errors.New("删除语句条件为空")
+	}
+	if m.ignoreTenant {
+		builder.IgnoreTenant()
+	}
+	sql, values := builder.WithDialect(m.dialect).Build()
+	return getRowsAffected(m.exec(ctx, sql, values...))
+}
+
+// 按插入构建器插入数据，返回影响行数
+func (m *session) InsertValues(builder *sbuilder.InsertBuilder) (int64, error) {
+	return m.InsertValuesContext(m.context(), builder)
+}
+
+// 按插入构建器插入数据（带上下文）
+func (m *session) InsertValuesContext(ctx context.Context, builder *sbuilder.InsertBuilder) (int64, error) {
+	if m.ignoreTenant {
+		builder.IgnoreTenant()
+	} else if builder.TenantMissing() {
+		return 0, ErrTenantMissing
+	}
+	if err := builder.Encrypt(); err != nil {
+		return 0, err
+	}
+	sql, values := builder.WithDialect(m.dialect).Build()
+	return getRowsAffected(m.exec(ctx, sql, values...))
+}
+
+// 分页查询数据
+func (m *session) SelectPage(data interface{}, page model.PageInfo, sql string, values ...interface{}) *model.PageResult {
+	return m.SelectPageContext(m.context(), data, page, sql, values...)
+}
+
+// 分页查询数据（带上下文）
+func (m *session) SelectPageContext(ctx context.Context, data interface{}, page model.PageInfo, sql string, values ...interface{}) *model.PageResult {
+	sql, values, err := m.tenantSQL(data, sql, values)
+	if err != nil {
+		log.Printf("执行SQL异常:%s\n %v", sql, err)
+		return nil
+	}
+	sql, values = m.scopeSQL(data, sql, values)
+	result, err := m.selectPage(ctx, data, page, sql, values...)
+	if err != nil || result.Total == 0 && !page.SkipCount && !page.IsCursor() {
+		return nil
+	}
+	return result
+}
+
+// 分页查询，无数据时返回 Total 为0的结果；游标分页及 SkipCount 时不查询总条数
+func (m *session) selectPage(ctx context.Context, data interface{}, page model.PageInfo, sql string, values ...interface{}) (*model.PageResult, error) {
+	if page.IsCursor() {
+		return m.cursorPage(ctx, data, page, sql, values...)
+	}
+	if page.Current == 0 {
+		page.Current = 1
+	}
+	result := &model.PageResult{Current: page.Current, Size: page.Size}
+	if !page.SkipCount {
+		count, err := m.selectCount(ctx, sql, values...)
+		if err != nil || count == 0 {
+			return result, err
+		}
+		result.Total = int64(count)
+	}
+	offset := (page.Current - 1) * page.Size
+	// 排序列只允许结果结构体的列及 AllowOrder 指定的列
+	allowed := sbuilder.OrderColumnsOf(data).Allow(m.orders...)
+	orderBy := sbuilder.BuildOrderBy(m.dialect, page.Orders, allowed)
+	var pageValues []interface{}
+	sql, pageValues = m.dialect.Page(fmt.Sprintf("select * from (%s) t", sql), orderBy, page.Size, offset)
+	values = append(values, pageValues...)
+	err := m.list(ctx, data, sql, values...)
+	if err != nil {
+		log.Printf("执行SQL异常: %v\n", err)
+		return nil, err
+	}
+	result.Rows = data
+	return result, nil
+}
+
+// 按结果结构体声明的数据权限包装查询语句：select * from (sql) t where 条件
+func (m *session) scopeSQL(data interface{}, sql string, values []interface{}) (string, []interface{}) {
+	if m.ignoreScope {
+		return sql, values
+	}
+	scope := sbuilder.DataScope(data, "t")
+	if scope == nil {
+		return sql, values
+	}
+	where, vs := scope.Build(m.dialect)
+	if where == "" {
+		return sql, values
+	}
+	args := make([]interface{}, 0, len(values)+len(vs))
+	args = append(append(args, values...), vs...)
+	return fmt.Sprintf("select * from (%s) t where %s", sql, where), args
+}
+
+// 多租户：原生SQL的查询结果含租户列时包装为 select * from (sql) t where t.租户列 = ?；
+// 结果不含租户列时原样执行（构建器生成的SQL已追加租户条件），SQL中的表均在忽略列表中时不处理
+func (m *session) tenantSQL(data interface{}, sql string, values []interface{}) (string, []interface{}, error) {
+	column, ok := m.rawTenant(sql)
+	if !ok {
+		return sql, values, nil
+	}
+	t := resultType(data)
+	if t == nil {
+		return sql, values, nil
+	}
+	if _, has := sbuilder.FieldByColumn(reflect.New(t).Interface(), column); !has {
+		return sql, values, nil
+	}
+	return m.wrapTenant(sql, values, column)
+}
+
+// 原生SQL需要处理的租户列：未开启多租户、IgnoreTenant 或SQL中的表均在忽略列表中时返回false
+func (m *session) rawTenant(sql string) (string, bool) {
+	if m.ignoreTenant {
+		return "", false
+	}
+	column, _ := sbuilder.Tenant("")
+	if column == "" {
+		return "", false
+	}
+	tables := sqlTables(sql)
+	for _, table := range tables {
+		if c, _ := sbuilder.Tenant(table); c != "" {
+			return column, true
+		}
+	}
+	return column, len(tables) == 0
+}
+
+// 按租户列包装查询语句，没有当前租户时返回 ErrTenantMissing
+func (m *session) wrapTenant(sql string, values []interface{}, column string) (string, []interface{}, error) {
+	_, value := sbuilder.Tenant("")
+	if value == nil {
+		return sql, nil, ErrTenantMissing
+	}
+	args := append(append(make([]interface{}, 0, len(values)+1), values...), value)
+	return fmt.Sprintf("select * from (%s) t where %s = ?", sql, m.dialect.Quote("t."+column)), args, nil
+}
+
+// 查询语句结果是否含指定列：执行不返回数据的查询读取列名
+func (m *session) hasColumn(ctx context.Context, sql string, values []interface{}, column string) (bool, error) {
+	probe := fmt.Sprintf("select * from (%s) t where 1 = 0", sql)
+	rows, err := m.reader().QueryxContext(ctx, m.dialect.Rebind(probe), values...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, err
+	}
+	for _, v := range columns {
+		if strings.EqualFold(v, column) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SQL中 from、join 之后的表名（不含子查询），用于判断租户忽略列表
+func sqlTables(sql string) []string {
+	result := make([]string, 0)
+	tokens := sqlTokens(sql)
+	inFrom := false
+	for i := 0; i < len(tokens); i++ {
+		lower := strings.ToLower(tokens[i])
+		switch {
+		case lower == "from" || lower == "join":
+			inFrom = true
+		case inFrom && lower == ",":
+		case inFrom && isIdent(tokens[i]):
+			result = append(result, identName(tokens[i]))
+			// 跳过别名
+			if i+1 < len(tokens) && strings.EqualFold(tokens[i+1], "as") {
+				i++
+			}
+			if i+1 < len(tokens) && isIdent(tokens[i+1]) && !sqlKeywords[strings.ToLower(tokens[i+1])] {
+				i++
+			}
+			inFrom = i+1 < len(tokens) && tokens[i+1] == ","
+		default:
+			inFrom = false
+		}
+	}
+	return result
+}
+
+// 表名之后可能出现的关键字
+var sqlKeywords = map[string]bool{"where": true, "join": true, "left": true, "right": true, "inner": true, "outer": true, "full": true, "cross": true,
+	"on": true, "group": true, "order": true, "limit": true, "union": true, "having": true, "offset": true, "fetch": true}
+
+// 查询结果的结构体类型：data 为结构体指针或结构体切片指针，其它类型返回nil
+func resultType(data interface{}) reflect.Type {
+	t := reflect.TypeOf(data)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+		return nil
+	}
+	return t
+}
+
+// 执行查询语句（? 占位符，执行前按方言转换），可直接使用构建器 Build() 的结果
+func (m *session) selectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	query, args, err := m.tenantSQL(dest, query, args)
+	if err == nil {
+		err = m.list(ctx, dest, query, args...)
+	}
+	if err != nil {
+		log.Printf("执行SQL异常:%v\n", err)
+	}
+	return err
+}
+
+// 按查询对象生成查询语句
+func (m *session) querySQL(query interface{}, columns []string) (string, []interface{}, error) {
+	return m.selectSQL(m.getField(query, 0), columns)
+}
+
+// 生成查询语句，字段解析错误（如加密字段不支持的条件）时返回错误
+func (m *session) selectSQL(info *sbuilder.StructInfo, columns []string) (string, []interface{}, error) {
+	if info.Err != nil {
+		return "", nil, info.Err
+	}
+	var cols string
+	if len(columns) == 0 {
+		cols = " * "
+	} else {
+		cols = quoteColumns(m.dialect, columns)
+	}
+	sql := fmt.Sprintf("select %s from %s where 1=1 ", cols, m.dialect.Quote(info.TableName))
+	condi := sbuilder.BuildQueryDialect(info, m.dialect)
+	sql += condi.Sql.String()
+	return sql, condi.Values, nil
+}
+
+// 查询集合
+func (m *session) SelectList(data interface{}, query interface{}, columns ...string) error {
+	return m.SelectListContext(m.context(), data, query, columns...)
+}
+
+// 查询集合（带上下文）
+func (m *session) SelectListContext(ctx context.Context, data interface{}, query interface{}, columns ...string) error {
+	sql, values, err := m.querySQL(query, columns)
+	if err == nil {
+		err = m.list(ctx, data, sql, values...)
+	}
+	if err != nil {
+		log.Printf("执行SQL异常:%v\n", err)
+		return err
+	}
+	return nil
+}
+
+// 查询一条记录
+func (m *session) SelectOne(data interface{}, query interface{}, columns ...string) error {
+	return m.SelectOneContext(m.context(), data, query, columns...)
+}
+
+// 查询一条记录（带上下文）
+func (m *session) SelectOneContext(ctx context.Context, data interface{}, query interface{}, columns ...string) error {
+	sql, values, err := m.querySQL(query, columns)
+	if err == nil {
+		err = m.get(ctx, data, sql, values...)
+	}
+	if err != nil {
+		log.Printf("执行SQL异常:%v\n", err)
+		return err
+	}
+	return nil
+}
+
+// 查询一条记录
+func (m *session) GetOne(data interface{}, columns ...string) error {
+	return m.GetOneContext(m.context(), data, columns...)
+}
+
+// 查询一条记录（带上下文）
+func (m *session) GetOneContext(ctx context.Context, data interface{}, columns ...string) error {
+	return m.SelectOneContext(ctx, data, data, columns...)
+}
+
+// 引用查询列
+func quoteColumns(dialect sbuilder.Dialect, columns []string) string {
+	cols := make([]string, 0, len(columns))
+	for _, column := range columns {
+		cols = append(cols, dialect.Quote(column))
+	}
+	return strings.Join(cols, ", ")
+}
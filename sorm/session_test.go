@@ -0,0 +1,126 @@
+package sorm
+
+import (
+	"testing"
+
+	"github.com/androidsr/sc-go/sbuilder"
+	"github.com/androidsr/sc-go/syaml"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type testUser struct {
+	Id      string `db:"id,primary_key"`
+	Name    string `db:"name"`
+	Deleted int    `db:"deleted,logic_delete"`
+}
+
+func (testUser) TableName() string {
+	return "test_user"
+}
+
+type testRole struct {
+	Id   string `db:"id,primary_key"`
+	Name string `db:"name"`
+}
+
+func (testRole) TableName() string {
+	return "test_role"
+}
+
+func newTestDB(t *testing.T) *Sorm {
+	db := New(&syaml.SqlxInfo{Driver: "sqlite3", Url: ":memory:", MaxOpen: 1, MaxIdle: 1, LogLevel: "silent"})
+	if db == nil {
+		t.Fatal("创建数据库失败")
+	}
+	db.MustExec("create table test_user (id text primary key, name text, deleted int default 0)")
+	db.MustExec("create table test_role (id text primary key, name text)")
+	db.MustExec("insert into test_user (id, name) values ('1', 'a'), ('2', 'b')")
+	db.MustExec("insert into test_role (id, name) values ('1', 'a'), ('2', 'b')")
+	return db
+}
+
+func TestDeleteWithoutCondition(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.Delete(&testUser{}); err == nil {
+		t.Fatal("逻辑删除无条件未报错")
+	}
+	if err := db.Delete(&testRole{}); err == nil {
+		t.Fatal("物理删除无条件未报错")
+	}
+	if err := NewRepo[testRole](db).DeleteById(""); err == nil {
+		t.Fatal("空主键删除未报错")
+	}
+	if _, err := NewRepo[testUser](db).FindById(nil); err == nil {
+		t.Fatal("空主键查询未报错")
+	}
+	if err := db.Delete(&testUser{Id: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.SelectCount("select * from test_user where deleted = 0"); n != 1 {
+		t.Fatal(n)
+	}
+	if n := db.SelectCount("select * from test_role"); n != 2 {
+		t.Fatal(n)
+	}
+}
+
+type testSecret struct {
+	Id    string `db:"id,primary_key"`
+	Phone string `db:"phone" encrypt:"aes,deterministic"`
+	Card  string `db:"card" encrypt:"aes"`
+	Name  string `db:"name"`
+}
+
+func (testSecret) TableName() string {
+	return "test_secret"
+}
+
+func TestEncryptCondition(t *testing.T) {
+	db := newTestDB(t)
+	db.MustExec("create table test_secret (id text primary key, phone text, card text, name text)")
+	sbuilder.SetEncryptKeys("k1", map[string][]byte{"k1": []byte("0123456789abcdef")})
+	defer sbuilder.SetEncryptKeys("", nil)
+	if err := db.Insert(&testSecret{Id: "1", Phone: "138", Card: "110", Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	// 轮换密钥后按旧密钥的密文仍可匹配
+	sbuilder.SetEncryptKeys("k2", map[string][]byte{"k1": []byte("0123456789abcdef"), "k2": []byte("fedcba9876543210")})
+	if err := db.Update(&testSecret{Phone: "138", Name: "b"}, "phone"); err != nil {
+		t.Fatal(err)
+	}
+	var list []testSecret
+	if err := db.SelectList(&list, &testSecret{Phone: "138"}); err != nil || len(list) != 1 || list[0].Name != "b" || list[0].Card != "110" {
+		t.Fatal(list, err)
+	}
+	if err := db.SelectList(&list, &testSecret{Card: "110"}); err == nil {
+		t.Fatal("非确定性加密字段作为条件未报错")
+	}
+	if err := db.Update(&testSecret{Card: "110", Name: "c"}, "card"); err == nil {
+		t.Fatal("非确定性加密字段作为更新条件未报错")
+	}
+}
+
+type testVersion struct {
+	Id      string `db:"id,primary_key"`
+	Name    string `db:"name"`
+	Version int    `db:"version,version"`
+}
+
+func TestUpdateVersionWithoutId(t *testing.T) {
+	db := newTestDB(t)
+	db.MustExec("create table test_version (id text primary key, name text, version int)")
+	db.MustExec("insert into test_version values ('1', 'a', 1), ('2', 'b', 1)")
+	if err := db.UpdateById(&testVersion{Name: "x", Version: 1}); err == nil {
+		t.Fatal("空主键更新未报错")
+	}
+	if err := NewRepo[testVersion](db).UpdateById(&testVersion{Name: "x", Version: 1}); err == nil {
+		t.Fatal("空主键更新未报错")
+	}
+	if n := db.SelectCount("select * from test_version where name = 'x'"); n != 0 {
+		t.Fatal("记录被更新", n)
+	}
+	if err := db.UpdateById(&testVersion{Id: "1", Name: "x", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
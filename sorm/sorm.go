@@ -1,19 +1,22 @@
 package sorm
 
 import (
-	"bytes"
+	"context"
 	"database/sql"
-	"errors"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 
-	"github.com/androidsr/sc-go/model"
 	"github.com/androidsr/sc-go/sbuilder"
-	"github.com/androidsr/sc-go/sc"
+	"github.com/androidsr/sc-go/sgin"
+	"github.com/androidsr/sc-go/smigrate"
 	"github.com/androidsr/sc-go/syaml"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
 )
 
 var (
@@ -21,339 +24,292 @@ var (
 )
 
 func New(config *syaml.SqlxInfo) *Sorm {
-	db, err := sqlx.Open(config.Driver, config.Url)
-	if err != nil {
-		log.Printf("数据库初始化失败:%s", err.Error())
+	pSqlx := newSorm(config)
+	if pSqlx == nil {
 		return nil
 	}
-	db.SetMaxIdleConns(config.MaxIdle)
-	db.SetMaxOpenConns(config.MaxOpen)
-	err = db.Ping()
-	if err != nil {
-		log.Printf("数据库连接异常：%s", err.Error())
+	sbuilder.SetDialect(pSqlx.dialect)
+	sbuilder.SetLogicDelete(config.LogicDeleted, config.LogicActive)
+	sbuilder.SetTablePrefix(config.TablePrefix)
+	setTenant(config.Tenant)
+	setDataScope(config.DataScope)
+	if err := setEncrypt(config.Encrypt); err != nil {
+		log.Printf("字段加密初始化失败:%s", err.Error())
 		return nil
 	}
-	pSqlx := &Sorm{db, config}
+	if err := loadTemplates(config.Templates); err != nil {
+		log.Printf("加载SQL模板失败:%s", err.Error())
+		return nil
+	}
+	for name, v := range config.Datasources {
+		if err := checkDatasource(name, v); err != nil {
+			log.Printf("命名数据源初始化失败:%s", err.Error())
+			return nil
+		}
+	}
+	sources := make(map[string]*Sorm, len(config.Datasources))
+	for name, v := range config.Datasources {
+		ds := newSorm(v)
+		if ds == nil {
+			log.Printf("命名数据源初始化失败:%s", name)
+			return nil
+		}
+		sources[name] = ds
+	}
+	Register("", pSqlx)
+	for name, ds := range sources {
+		Register(name, ds)
+	}
 	return pSqlx
 }
 
-type Sorm struct {
-	*sqlx.DB
-	config *syaml.SqlxInfo
+// 按配置开启多租户，租户ID取当前请求上下文（JWT）中的值
+func setTenant(config *syaml.TenantInfo) {
+	if config == nil {
+		return
+	}
+	column, key := config.Column, config.Key
+	if column == "" {
+		column = "tenant_id"
+	}
+	if key == "" {
+		key = "tenantId"
+	}
+	sbuilder.SetTenant(column, func() interface{} {
+		return claimValue(sgin.GetValue(key))
+	}, config.Ignore...)
+}
+
+// 按配置开启数据权限，当前用户取请求上下文（JWT）中的值
+func setDataScope(config *syaml.DataScopeInfo) {
+	if config == nil {
+		return
+	}
+	keys := []string{config.UserKey, config.DeptKey, config.DeptIdsKey, config.ScopeKey}
+	for i, v := range []string{"userId", "deptId", "deptIds", "dataScope"} {
+		if keys[i] == "" {
+			keys[i] = v
+		}
+	}
+	sbuilder.SetDataScope(func() *sbuilder.ScopeUser {
+		userId := claimValue(sgin.GetValue(keys[0]))
+		if userId == nil {
+			return nil
+		}
+		user := &sbuilder.ScopeUser{UserId: userId, DeptId: claimValue(sgin.GetValue(keys[1]))}
+		switch v := sgin.GetValue(keys[2]).(type) {
+		case []interface{}:
+			for _, id := range v {
+				if id = claimValue(id); id != nil {
+					user.DeptIds = append(user.DeptIds, id)
+				}
+			}
+		case string:
+			for _, id := range strings.Split(v, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					user.DeptIds = append(user.DeptIds, id)
+				}
+			}
+		}
+		user.Scope, _ = sgin.GetValue(keys[3]).(string)
+		return user
+	})
 }
 
-// 判断数据是否存在
-func (m *Sorm) Exists(obj interface{}) bool {
-	count := m.GetCount(obj)
-	return count > 0
+// 统一上下文（JWT）中的ID：json.Number 按原文转为 int64，不是整数时保留字符串；
+// float64 超过 2^53 时已丢失精度，视为没有值（不匹配任何数据），此类ID需以字符串写入JWT；空字符串视为没有值
+func claimValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		return value.String()
+	case float64:
+		if math.Abs(value) > 1<<53 {
+			log.Printf("数据权限ID超出精度范围:%v", value)
+			return nil
+		}
+		if value == math.Trunc(value) {
+			return int64(value)
+		}
+	case string:
+		if value == "" {
+			return nil
+		}
+	}
+	return v
 }
 
-// 按条件获取数据条数
-func (m *Sorm) GetCount(obj interface{}) int {
-	info := sbuilder.GetField(obj, 0)
-	builder := sbuilder.BuildQuery(info)
-	sql := fmt.Sprintf("select count(*) from %s where 1=1 %s", info.TableName, builder.Sql.String())
-	var count int
-	err := m.DB.Get(&count, sql, builder.Values...)
+// 按配置设置字段加密密钥
+func setEncrypt(config *syaml.EncryptInfo) error {
+	if config == nil {
+		return nil
+	}
+	keys := make(map[string][]byte, len(config.Keys))
+	for id, v := range config.Keys {
+		key, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return fmt.Errorf("密钥格式错误 %s: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return sbuilder.SetEncryptKeys(config.Key, keys)
+}
+
+// 创建数据源：主库及只读副本，不修改全局方言
+func newSorm(config *syaml.SqlxInfo) *Sorm {
+	dialect := sbuilder.GetDialect(config.Driver)
+	db, err := open(config, config.Url, dialect)
 	if err != nil {
-		log.Printf("执行SQL异常:%s\n %v", sql, err)
-		return 0
+		return nil
+	}
+	replicas := make([]*sqlx.DB, 0, len(config.Replicas))
+	for _, url := range config.Replicas {
+		replica, err := open(config, url, dialect)
+		if err != nil {
+			log.Printf("只读副本不可用，已忽略:%s", err.Error())
+			continue
+		}
+		replicas = append(replicas, replica)
+	}
+	if err := smigrate.Auto(db.DB, config.Driver, config.Migrate); err != nil {
+		return nil
 	}
-	return count
+	interceptors := defaultInterceptors(config.LogLevel, config.SlowSql, config.Redact)
+	return &Sorm{db, &session{db: db, config: config, dialect: dialect, interceptors: interceptors, replicas: replicas, next: new(uint32)}}
 }
 
-// 数据总条数
-func (m *Sorm) SelectCount(sql string, values ...interface{}) int {
-	var count int
-	sql = fmt.Sprintf("select count(*) from (%s) t", sql)
-	printSQL(sql, values...)
-	err := m.DB.Get(&count, sql, values...)
+// 打开数据库连接并按配置设置连接池
+func open(config *syaml.SqlxInfo, url string, dialect sbuilder.Dialect) (*sqlx.DB, error) {
+	db, err := sqlx.Open(config.Driver, url)
 	if err != nil {
-		log.Printf("执行SQL异常:%s\n %v", sql, err)
-		return 0
+		log.Printf("数据库初始化失败:%s", err.Error())
+		return nil, err
+	}
+	db.SetMaxIdleConns(config.MaxIdle)
+	db.SetMaxOpenConns(config.MaxOpen)
+	err = db.Ping()
+	if err != nil {
+		log.Printf("数据库连接异常：%s", err.Error())
+		db.Close()
+		return nil, err
 	}
-	return count
+	if dialect.Name() == sbuilder.Oracle {
+		// Oracle 返回的列名为大写
+		db.Mapper = reflectx.NewMapperTagFunc("db", strings.ToUpper, strings.ToUpper)
+	}
+	return db, nil
 }
 
-// 插入数据
-func (m *Sorm) Insert(obj interface{}) error {
-	info := sbuilder.GetField(obj, 1)
-	columns, values := info.GetDbValues(sbuilder.EXEC)
-	sql := insertSQL(info.TableName, columns)
-	printSQL(sql, values...)
-	ret, err := m.DB.Exec(sql, values...)
-	return getAffectedRow(ret, err)
+type Sorm struct {
+	*sqlx.DB
+	*session
 }
 
-// 插入数据（同一事物db）
-func (m *Sorm) InsertTx(db *sqlx.Tx, obj interface{}) error {
-	info := sbuilder.GetField(obj, 1)
-	column, values := info.GetDbValues(sbuilder.EXEC)
-	sql := insertSQL(info.TableName, column)
-	printSQL(sql, values...)
-	ret, err := db.Exec(sql, values...)
-	return getAffectedRow(ret, err)
+// 查询集合：覆盖 sqlx.DB.Select，SQL 使用 ? 占位符并经过拦截器
+func (m *Sorm) Select(dest interface{}, query string, args ...interface{}) error {
+	return m.session.selectContext(m.context(), dest, query, args...)
 }
 
-func insertSQL(tableName string, columns []string) string {
-	vals := make([]string, 0)
-	for i := 0; i < len(columns); i++ {
-		vals = append(vals, "?")
-	}
-	sql := fmt.Sprintf("insert into %s(%s) values (%s)", tableName, strings.Join(columns, ", "), strings.Join(vals, ", "))
-	return sql
+// 查询集合（带上下文）
+func (m *Sorm) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return m.session.selectContext(ctx, dest, query, args...)
 }
 
-// 按ID更新非空字段
-func (m *Sorm) UpdateById(obj interface{}) error {
-	info := sbuilder.GetField(obj, 2)
-	column, values := info.GetDbValues(sbuilder.EXEC)
-	sql, values := updateSQL(info.TableName, column, values, info.PrimaryKey)
-	printSQL(sql, values...)
-	ret, err := m.DB.Exec(sql, values...)
-	return getAffectedRow(ret, err)
+// 返回不处理逻辑删除的对象：查询包含已删除数据，删除为物理删除
+func (m *Sorm) Unscoped() *Sorm {
+	s := m.with(m.DB)
+	s.unscoped = true
+	return &Sorm{DB: m.DB, session: s}
 }
 
-// 更新数据（指定条件列）
-func (m *Sorm) Update(obj interface{}, condition ...string) error {
-	if len(condition) == 0 {
-		return errors.New("更新语句条件为空")
-	}
-	info := sbuilder.GetField(obj, 2)
-	column, values := info.GetDbValues(sbuilder.EXEC)
-	sql, values := updateSQL(info.TableName, column, values, info.PrimaryKey)
-	printSQL(sql, values...)
-	ret, err := m.DB.Exec(sql, values...)
-	return getAffectedRow(ret, err)
+// 返回查询也使用主库的对象，用于写入后立即读取等场景
+func (m *Sorm) ForcePrimary() *Sorm {
+	s := m.with(m.DB)
+	s.primary = true
+	return &Sorm{DB: m.DB, session: s}
 }
 
-// 更新数据（指定条件列，同一事物db）
-func (m *Sorm) UpdateTx(db *sqlx.Tx, obj interface{}, condition ...string) error {
-	if len(condition) == 0 {
-		return errors.New("更新语句条件为空")
-	}
-	info := sbuilder.GetField(obj, 2)
-	column, values := info.GetDbValues(sbuilder.EXEC)
-	sql, values := updateSQL(info.TableName, column, values, info.PrimaryKey)
-	printSQL(sql, values...)
-	ret, err := db.Exec(sql, values...)
-	return getAffectedRow(ret, err)
+// 返回不处理多租户的对象，用于管理员、定时任务等跨租户操作
+func (m *Sorm) IgnoreTenant() *Sorm {
+	s := m.with(m.DB)
+	s.ignoreTenant = true
+	return &Sorm{DB: m.DB, session: s}
 }
 
-func updateSQL(tableName string, columns []string, values []interface{}, condition ...string) (string, []interface{}) {
-	sets := bytes.Buffer{}
-	conds := bytes.Buffer{}
-	setValues := make([]interface{}, 0)
-	condValues := make([]interface{}, 0)
-	for i, column := range columns {
-		if sc.Contains(condition, column) {
-			conds.WriteString(fmt.Sprintf(" and %s = ?", column))
-			condValues = append(condValues, values[i])
-		} else {
-			sets.WriteString(fmt.Sprintf(" %s = ?,", column))
-			setValues = append(setValues, values[i])
-		}
-	}
-	sql := fmt.Sprintf("update %s set %s where 1=1 %s", tableName, sets.String()[:sets.Len()-1], conds.String())
-	return sql, append(setValues, condValues...)
+// 返回不处理数据权限的对象，用于统计、定时任务等需要全部数据的操作
+func (m *Sorm) IgnoreDataScope() *Sorm {
+	s := m.with(m.DB)
+	s.ignoreScope = true
+	return &Sorm{DB: m.DB, session: s}
 }
 
-// 删除数据
-func (m *Sorm) Delete(obj interface{}) error {
-	info := sbuilder.GetField(obj, 0)
-	column, values := info.GetDbValues(sbuilder.EXEC)
-	sql := deleteSQL(info.TableName, column)
-	printSQL(sql, values...)
-	ret, err := m.DB.Exec(sql, values...)
-	return getAffectedRow(ret, err)
+// 返回插入、更新时忽略指定列的对象
+func (m *Sorm) Omit(columns ...string) *Sorm {
+	s := m.with(m.DB)
+	s.omit = columns
+	return &Sorm{DB: m.DB, session: s}
 }
 
-// 删除数据（同一事务db）
-func (m *Sorm) DeleteTx(db *sqlx.Tx, obj interface{}) error {
-	info := sbuilder.GetField(obj, 0)
-	column, values := info.GetDbValues(sbuilder.EXEC)
-	sql := deleteSQL(info.TableName, column)
-	printSQL(sql, values...)
-	ret, err := db.Exec(sql, values...)
-	return getAffectedRow(ret, err)
+// 返回分页查询额外允许指定排序列的对象（结果结构体的列默认允许）
+func (m *Sorm) AllowOrder(columns ...string) *Sorm {
+	s := m.with(m.DB)
+	s.orders = columns
+	return &Sorm{DB: m.DB, session: s}
 }
 
-func deleteSQL(tableName string, columns []string) string {
-	condition := bytes.Buffer{}
-	for _, column := range columns {
-		condition.WriteString(fmt.Sprintf(" and %s = ? ", column))
-	}
-	sql := fmt.Sprintf("delete from %s where 1=1 %s", tableName, condition.String())
-	return sql
+// 插入数据（同一事物db）
+func (m *Sorm) InsertTx(db *sqlx.Tx, obj interface{}) error {
+	return m.with(db).Insert(obj)
 }
 
-// 分页查询数据
-func (m *Sorm) SelectPage(data interface{}, page model.PageInfo, sql string, values ...interface{}) *model.PageResult {
-	result := new(model.PageResult)
-	if &page != nil {
-		if page.Current == 0 {
-			page.Current = 1
-		}
-		count := m.SelectCount(sql, values...)
-		result.Current = page.Current
-		result.Size = page.Size
-		if count == 0 {
-			return nil
-		}
-		result.Total = int64(count)
-		offset := (page.Current - 1) * page.Size
-		result.Current = offset
-		orderBy := bytes.Buffer{}
-		if page.Orders != nil {
-			orderBy.WriteString("order by ")
-			for i, v := range page.Orders {
-				orderBy.WriteString(fmt.Sprintf(" %s ", v.Column))
-				if v.Asc {
-					orderBy.WriteString("asc")
-				} else {
-					orderBy.WriteString("desc")
-				}
-				if i != len(page.Orders)-1 {
-					orderBy.WriteString(", ")
-				}
-			}
-		}
-		sql = fmt.Sprintf("select * from (%s) t %s LIMIT ? OFFSET ?", sql, orderBy.String())
-		values = append(values, page.Size, offset)
-	}
-	printSQL(sql, values...)
-	err := m.DB.Select(data, sql, values...)
-	if err != nil {
-		log.Printf("执行SQL异常: %v\n", err)
-		return nil
-	}
-	result.Rows = data
-	return result
+// 批量插入数据（同一事务db）
+func (m *Sorm) InsertBatchTx(tx *sqlx.Tx, objs interface{}, chunkSize int) (int64, error) {
+	return m.with(tx).InsertBatch(objs, chunkSize)
 }
 
-// 查询集合
-func (m *Sorm) SelectList(data interface{}, query interface{}, columns ...string) error {
-	info := sbuilder.GetField(query, 0)
-	_, values := info.GetDbValues(sbuilder.QUERY)
-	var cols string
-	if len(columns) == 0 {
-		cols = " * "
-	} else {
-		cols = strings.Join(columns, ", ")
-	}
-	sql := fmt.Sprintf("select %s from %s where 1=1 ", cols, info.TableName)
-	condi := sbuilder.BuildQuery(info)
-	sql += condi.Sql.String()
-	err := m.DB.Select(data, sql, values...)
-	if err != nil {
-		log.Printf("执行SQL异常:%v\n", err)
-		return err
-	}
-	return nil
+// 插入或更新数据（同一事务db）
+func (m *Sorm) UpsertTx(tx *sqlx.Tx, obj interface{}, conflict ...string) (int64, error) {
+	return m.with(tx).Upsert(obj, conflict...)
 }
 
-// 查询集合
-func (m *Sorm) SelectListTx(tx *sqlx.Tx, data interface{}, query interface{}, columns ...string) error {
-	info := sbuilder.GetField(query, 0)
-	_, values := info.GetDbValues(sbuilder.QUERY)
-	var cols string
-	if len(columns) == 0 {
-		cols = " * "
-	} else {
-		cols = strings.Join(columns, ", ")
-	}
-	sql := fmt.Sprintf("select %s from %s where 1=1 ", cols, info.TableName)
-	condi := sbuilder.BuildQuery(info)
-	sql += condi.Sql.String()
-	err := tx.Select(data, sql, values...)
-	if err != nil {
-		log.Printf("执行SQL异常:%v\n", err)
-		return err
-	}
-	return nil
+// 更新数据（指定条件列，同一事物db）
+func (m *Sorm) UpdateTx(db *sqlx.Tx, obj interface{}, condition ...string) error {
+	return m.with(db).Update(obj, condition...)
 }
 
-// 查询一条记录
-func (m *Sorm) SelectOne(data interface{}, query interface{}, columns ...string) error {
-	info := sbuilder.GetField(query, 0)
-	_, values := info.GetDbValues(sbuilder.QUERY)
-	var cols string
-	if len(columns) == 0 {
-		cols = " * "
-	} else {
-		cols = strings.Join(columns, ", ")
-	}
-	sql := fmt.Sprintf("select %s from %s where 1=1 ", cols, info.TableName)
-	condi := sbuilder.BuildQuery(info)
-	sql += condi.Sql.String()
-	printSQL(sql, values...)
-	err := m.DB.Get(data, sql, values...)
-	if err != nil {
-		log.Printf("执行SQL异常:%v\n", err)
-		return err
-	}
-	return nil
+// 删除数据（同一事务db）
+func (m *Sorm) DeleteTx(db *sqlx.Tx, obj interface{}) error {
+	return m.with(db).Delete(obj)
 }
 
-// 查询一条记录
-func (m *Sorm) GetOne(data interface{}, columns ...string) error {
-	info := sbuilder.GetField(data, 0)
-	_, values := info.GetDbValues(sbuilder.QUERY)
-	var cols string
-	if len(columns) == 0 {
-		cols = " * "
-	} else {
-		cols = strings.Join(columns, ", ")
-	}
-	sql := fmt.Sprintf("select %s from %s where 1=1 ", cols, info.TableName)
-	condi := sbuilder.BuildQuery(info)
-	sql += condi.Sql.String()
-	printSQL(sql, values...)
-	err := m.DB.Get(data, sql, values...)
-	if err != nil {
-		log.Printf("执行SQL异常:%v\n", err)
-		return err
-	}
-	return nil
+// 查询集合
+func (m *Sorm) SelectListTx(tx *sqlx.Tx, data interface{}, query interface{}, columns ...string) error {
+	return m.with(tx).SelectList(data, query, columns...)
 }
 
 // 查询一条记录
 func (m *Sorm) SelectOneTx(tx *sqlx.Tx, data interface{}, query interface{}, columns ...string) error {
-	info := sbuilder.GetField(query, 0)
-	_, values := info.GetDbValues(sbuilder.QUERY)
-	var cols string
-	if len(columns) == 0 {
-		cols = " * "
-	} else {
-		cols = strings.Join(columns, ", ")
-	}
-	sql := fmt.Sprintf("select %s from %s where 1=1 ", cols, info.TableName)
-	condi := sbuilder.BuildQuery(info)
-	sql += condi.Sql.String()
-	printSQL(sql, values...)
-	err := tx.Get(data, sql, values...)
-	if err != nil {
-		log.Printf("执行SQL异常:%v\n", err)
-		return err
-	}
-	return nil
+	return m.with(tx).SelectOne(data, query, columns...)
 }
 
-// 打印SQL信息
-func printSQL(sql string, values ...interface{}) {
-	fmt.Printf("执行SQL: %s\n%v\n", sql, values)
+// 获取SQL执行影响行数
+func getAffectedRow(ret sql.Result, err error) error {
+	_, err = getRowsAffected(ret, err)
+	return err
 }
 
 // 获取SQL执行影响行数
-func getAffectedRow(ret sql.Result, err error) error {
+func getRowsAffected(ret sql.Result, err error) (int64, error) {
 	if err != nil {
 		log.Printf("更新SQL失败: %v", err)
-		return err
+		return 0, err
 	}
-	_, err = ret.RowsAffected()
+	rows, err := ret.RowsAffected()
 	if err != nil {
 		log.Printf("更新SQL失败: %v", err)
-		return err
+		return 0, err
 	}
-	return nil
+	return rows, nil
 }
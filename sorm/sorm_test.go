@@ -0,0 +1,24 @@
+package sorm
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClaimValue(t *testing.T) {
+	if v := claimValue(json.Number("1234567890123456789")); v != int64(1234567890123456789) {
+		t.Fatal(v)
+	}
+	if v := claimValue(json.Number("1.5")); v != "1.5" {
+		t.Fatal(v)
+	}
+	if v := claimValue(float64(1234567890123456789)); v != nil {
+		t.Fatal("超出精度的ID未拒绝", v)
+	}
+	if v := claimValue(float64(12)); v != int64(12) {
+		t.Fatal(v)
+	}
+	if v := claimValue("1234567890123456789"); v != "1234567890123456789" {
+		t.Fatal(v)
+	}
+}
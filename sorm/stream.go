@@ -0,0 +1,151 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+// 迭代器中止遍历
+var errStopEach = errors.New("stop each")
+
+// 逐行查询：每行扫描到 newRow() 返回的结构体指针后调用 fn，fn 返回错误时停止遍历并返回该错误；
+// 结果不整体载入内存，适合导出大量数据，不受 timeout 配置限制（可通过 ctx 控制）。
+// 遍历期间连接被占用，fn 中不能使用同一事务执行其它SQL
+func (m *session) each(ctx context.Context, newRow func() interface{}, fn func(row interface{}) error, sql string, values ...interface{}) error {
+	// 回调的错误不作为SQL异常，回调耗时不计入SQL执行时间
+	var stop error
+	var paused time.Duration
+	err := m.interceptPaused(ctx, sql, values, &paused, func(context.Context) (int64, error) {
+		rows, err := m.reader().QueryxContext(ctx, m.dialect.Rebind(sql), values...)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		var count int64
+		for rows.Next() {
+			row := newRow()
+			if err := rows.StructScan(row); err != nil {
+				return count, err
+			}
+			count++
+			if err := sbuilder.DecryptFields(row); err != nil {
+				return count, err
+			}
+			if err := m.afterFind(ctx, row); err != nil {
+				return count, err
+			}
+			start := time.Now()
+			stop = fn(row)
+			paused += time.Since(start)
+			if stop != nil {
+				break
+			}
+		}
+		return count, rows.Err()
+	})
+	if err != nil {
+		return err
+	}
+	return stop
+}
+
+// 逐行查询，row 为结构体指针，每行扫描到 row 后调用 fn，fn 返回错误时停止遍历并返回该错误
+func (m *session) Each(row interface{}, fn func() error, sql string, values ...interface{}) error {
+	return m.EachContext(m.context(), row, fn, sql, values...)
+}
+
+// 逐行查询（带上下文）
+func (m *session) EachContext(ctx context.Context, row interface{}, fn func() error, sql string, values ...interface{}) error {
+	sql, values, err := m.tenantSQL(row, sql, values)
+	if err != nil {
+		return err
+	}
+	return m.each(ctx, func() interface{} { return row }, func(interface{}) error { return fn() }, sql, values...)
+}
+
+// 按查询对象逐行查询，fn 返回错误时停止遍历并返回该错误
+func (m *Repo[T]) Each(query *T, fn func(row *T) error) error {
+	return m.EachContext(m.s.context(), query, fn)
+}
+
+// 按查询对象逐行查询（带上下文）
+func (m *Repo[T]) EachContext(ctx context.Context, query *T, fn func(row *T) error) error {
+	sql, values, err := m.s.selectSQL(m.info(query), nil)
+	if err != nil {
+		return err
+	}
+	return m.eachSQL(ctx, fn, sql, values...)
+}
+
+// 按查询构建器逐行查询，fn 返回错误时停止遍历并返回该错误
+func (m *Repo[T]) EachSelect(builder *sbuilder.SelectBuilder, fn func(row *T) error) error {
+	return m.EachSelectContext(m.s.context(), builder, fn)
+}
+
+// 按查询构建器逐行查询（带上下文）
+func (m *Repo[T]) EachSelectContext(ctx context.Context, builder *sbuilder.SelectBuilder, fn func(row *T) error) error {
+	sql, values, err := m.builderSQL(builder)
+	if err != nil {
+		return err
+	}
+	return m.eachSQL(ctx, fn, sql, values...)
+}
+
+// 创建使用当前数据源方言的查询构建器
+func (m *Repo[T]) Builder(sql string) *sbuilder.SelectBuilder {
+	return sbuilder.Builder(sql).WithDialect(m.s.dialect)
+}
+
+// 查询构建器的条件在添加时按其方言生成，与数据源方言不一致时返回错误
+func (m *Repo[T]) builderSQL(builder *sbuilder.SelectBuilder) (string, []interface{}, error) {
+	if name := builder.GetDialect().Name(); name != m.s.dialect.Name() {
+		return "", nil, fmt.Errorf("查询构建器方言 %s 与数据源方言 %s 不一致，需在添加条件前调用 WithDialect", name, m.s.dialect.Name())
+	}
+	sql, values := builder.Build()
+	return m.s.tenantSQL(new(T), sql, values)
+}
+
+func (m *Repo[T]) eachSQL(ctx context.Context, fn func(row *T) error, sql string, values ...interface{}) error {
+	return m.s.each(ctx, func() interface{} { return new(T) }, func(row interface{}) error { return fn(row.(*T)) }, sql, values...)
+}
+
+// 按查询对象返回逐行迭代器：for row, err := range repo.Iter(ctx, query)，中途 break 时结束查询
+func (m *Repo[T]) Iter(ctx context.Context, query *T) iter.Seq2[*T, error] {
+	sql, values, err := m.s.selectSQL(m.info(query), nil)
+	if err != nil {
+		return func(yield func(*T, error) bool) {
+			yield(nil, err)
+		}
+	}
+	return m.iter(ctx, sql, values)
+}
+
+// 按查询构建器返回逐行迭代器
+func (m *Repo[T]) IterSelect(ctx context.Context, builder *sbuilder.SelectBuilder) iter.Seq2[*T, error] {
+	sql, values, err := m.builderSQL(builder)
+	if err != nil {
+		return func(yield func(*T, error) bool) {
+			yield(nil, err)
+		}
+	}
+	return m.iter(ctx, sql, values)
+}
+
+func (m *Repo[T]) iter(ctx context.Context, sql string, values []interface{}) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		err := m.eachSQL(ctx, func(row *T) error {
+			if !yield(row, nil) {
+				return errStopEach
+			}
+			return nil
+		}, sql, values...)
+		if err != nil && err != errStopEach {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+// 记录最后一条SQL执行信息
+type recordInterceptor struct {
+	stmt *Statement
+}
+
+func (m *recordInterceptor) Before(ctx context.Context, stmt *Statement) error {
+	return nil
+}
+
+func (m *recordInterceptor) After(ctx context.Context, stmt *Statement) {
+	m.stmt = stmt
+}
+
+func TestEachDuration(t *testing.T) {
+	db := newTestDB(t)
+	record := new(recordInterceptor)
+	db.Use(record)
+	count := 0
+	err := NewRepo[testRole](db).Each(&testRole{}, func(row *testRole) error {
+		count++
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	if err != nil || count != 2 {
+		t.Fatal(count, err)
+	}
+	if record.stmt.Rows != 2 || record.stmt.Duration >= 20*time.Millisecond {
+		t.Fatal("执行时间包含回调耗时", record.stmt.Duration)
+	}
+}
+
+func TestEachSelectDialect(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRepo[testRole](db)
+	sbuilder.SetDialect(sbuilder.GetDialect("sqlserver"))
+	builder := sbuilder.Builder("select * from test_role")
+	sbuilder.SetDialect(db.dialect)
+	builder.Like("name", "a")
+	if err := repo.EachSelect(builder, func(row *testRole) error { return nil }); err == nil {
+		t.Fatal("方言不一致未报错")
+	}
+	builder = repo.Builder("select * from test_role")
+	builder.Like("name", "a")
+	var names []string
+	for row, err := range repo.IterSelect(context.Background(), builder) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, row.Name)
+	}
+	if len(names) != 1 || names[0] != "a" {
+		t.Fatal(names)
+	}
+}
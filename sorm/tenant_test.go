@@ -0,0 +1,100 @@
+package sorm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/androidsr/sc-go/model"
+	"github.com/androidsr/sc-go/sbuilder"
+)
+
+type testDoc struct {
+	Id       string `db:"id,primary_key"`
+	Name     string `db:"name"`
+	TenantId string `db:"tenant_id"`
+}
+
+func (testDoc) TableName() string {
+	return "test_doc"
+}
+
+// 切换当前租户，返回恢复函数
+func useTenant(tenant *string) func() {
+	sbuilder.SetTenant("tenant_id", func() interface{} { return *tenant }, "test_user", "test_role")
+	return func() { sbuilder.SetTenant("", nil) }
+}
+
+func TestTenantUpsert(t *testing.T) {
+	db := newTestDB(t)
+	db.MustExec("create table test_doc (id text primary key, name text, tenant_id text)")
+	db.MustExec("insert into test_doc (id, name, tenant_id) values ('1', 'b', 'B')")
+	tenant := "A"
+	defer useTenant(&tenant)()
+	if _, err := db.Upsert(&testDoc{Id: "1", Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	var doc testDoc
+	if err := db.DB.Get(&doc, "select * from test_doc where id = '1'"); err != nil || doc.Name != "b" || doc.TenantId != "B" {
+		t.Fatal(doc, err)
+	}
+	tenant = "B"
+	if _, err := db.Upsert(&testDoc{Id: "1", Name: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DB.Get(&doc, "select * from test_doc where id = '1'"); err != nil || doc.Name != "c" || doc.TenantId != "B" {
+		t.Fatal(doc, err)
+	}
+}
+
+func TestTenantRawSQL(t *testing.T) {
+	db := newTestDB(t)
+	db.MustExec("create table test_doc (id text primary key, name text, tenant_id text)")
+	db.MustExec("insert into test_doc (id, name, tenant_id) values ('1', 'a', 'A'), ('2', 'b', 'B')")
+	tenant := "A"
+	defer useTenant(&tenant)()
+	var list []testDoc
+	if err := db.Select(&list, "select * from test_doc"); err != nil || len(list) != 1 || list[0].Id != "1" {
+		t.Fatal(list, err)
+	}
+	var rows []testDoc
+	if r := db.SelectPage(&rows, model.PageInfo{Current: 1, Size: 10}, "select * from test_doc"); r == nil || r.Total != 1 {
+		t.Fatal(r)
+	}
+	n := 0
+	err := NewRepo[testDoc](db).EachSelect(sbuilder.Builder("select * from test_doc"), func(row *testDoc) error {
+		n++
+		return nil
+	})
+	if err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+	// 构建器生成的SQL已追加租户条件，结果不含租户列时原样执行
+	var names []struct {
+		Name string `db:"name"`
+	}
+	sql, values := sbuilder.Select("name").From("test_doc").Build()
+	if err := db.Select(&names, sql, values...); err != nil || len(names) != 1 || names[0].Name != "a" {
+		t.Fatal(names, err)
+	}
+	if n := db.SelectCount("select * from test_doc"); n != 1 {
+		t.Fatal(n)
+	}
+	var counts []int
+	if err := db.Select(&counts, "select count(*) from test_doc where tenant_id = ?", "A"); err != nil || len(counts) != 1 || counts[0] != 1 {
+		t.Fatal(counts, err)
+	}
+	// SQL中的表均在忽略列表中时不处理
+	if n := db.SelectCount("select * from test_role r"); n != 2 {
+		t.Fatal(n)
+	}
+	if err := db.IgnoreTenant().Select(&names, "select name from test_doc"); err != nil || len(names) != 2 {
+		t.Fatal(names, err)
+	}
+}
+
+func TestSqlTables(t *testing.T) {
+	tables := sqlTables(`select * from sys_user u, sys_role as r left join "sys_dept" d on u.dept_id = d.id where u.id in (select user_id from sys_user_role)`)
+	if strings.Join(tables, ",") != "sys_user,sys_role,sys_dept,sys_user_role" {
+		t.Fatal(tables)
+	}
+}
@@ -0,0 +1,120 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 事务对象，提供与 Sorm 相同的数据操作，所有操作在同一事务内执行
+type SormTx struct {
+	*sqlx.Tx
+	*session
+}
+
+// 在事务中执行 fn：返回nil时提交，返回错误或发生panic时回滚
+func (m *Sorm) Transaction(ctx context.Context, fn func(tx *SormTx) error) error {
+	tx, err := m.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("开启事务失败: %v", err)
+		return err
+	}
+	s := m.with(tx)
+	stx := &SormTx{Tx: tx, session: s}
+	s.ctx = context.WithValue(ctx, txKey{}, stx)
+	return stx.run(fn, tx.Commit, tx.Rollback)
+}
+
+// 嵌套事务，使用保存点实现：fn 返回错误时仅回滚到保存点
+func (m *SormTx) Transaction(ctx context.Context, fn func(tx *SormTx) error) error {
+	name := fmt.Sprintf("sp_%d", m.depth+1)
+	save, rollback, release := m.dialect.Savepoint(name)
+	if _, err := m.exec(ctx, save); err != nil {
+		log.Printf("创建保存点失败: %v", err)
+		return err
+	}
+	s := m.with(m.Tx)
+	s.depth = m.depth + 1
+	stx := &SormTx{Tx: m.Tx, session: s}
+	s.ctx = context.WithValue(ctx, txKey{}, stx)
+	commit := func() error {
+		if release == "" {
+			return nil
+		}
+		_, err := m.exec(ctx, release)
+		return err
+	}
+	rollbackTo := func() error {
+		_, err := m.exec(ctx, rollback)
+		return err
+	}
+	return stx.run(fn, commit, rollbackTo)
+}
+
+// 查询集合：覆盖 sqlx.Tx.Select，SQL 使用 ? 占位符并经过拦截器
+func (m *SormTx) Select(dest interface{}, query string, args ...interface{}) error {
+	return m.session.selectContext(m.context(), dest, query, args...)
+}
+
+// 查询集合（带上下文）
+func (m *SormTx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return m.session.selectContext(ctx, dest, query, args...)
+}
+
+// 返回不处理逻辑删除的事务对象：查询包含已删除数据，删除为物理删除
+func (m *SormTx) Unscoped() *SormTx {
+	s := m.with(m.Tx)
+	s.unscoped = true
+	return &SormTx{Tx: m.Tx, session: s}
+}
+
+// 返回不处理多租户的事务对象
+func (m *SormTx) IgnoreTenant() *SormTx {
+	s := m.with(m.Tx)
+	s.ignoreTenant = true
+	return &SormTx{Tx: m.Tx, session: s}
+}
+
+// 返回不处理数据权限的事务对象
+func (m *SormTx) IgnoreDataScope() *SormTx {
+	s := m.with(m.Tx)
+	s.ignoreScope = true
+	return &SormTx{Tx: m.Tx, session: s}
+}
+
+// 返回插入、更新时忽略指定列的事务对象
+func (m *SormTx) Omit(columns ...string) *SormTx {
+	s := m.with(m.Tx)
+	s.omit = columns
+	return &SormTx{Tx: m.Tx, session: s}
+}
+
+// 返回分页查询额外允许指定排序列的事务对象（结果结构体的列默认允许）
+func (m *SormTx) AllowOrder(columns ...string) *SormTx {
+	s := m.with(m.Tx)
+	s.orders = columns
+	return &SormTx{Tx: m.Tx, session: s}
+}
+
+func (m *SormTx) run(fn func(tx *SormTx) error, commit func() error, rollback func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := rollback(); rbErr != nil {
+				log.Printf("事务回滚失败: %v", rbErr)
+			}
+			panic(p)
+		}
+	}()
+	if err = fn(m); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			log.Printf("事务回滚失败: %v", rbErr)
+		}
+		return err
+	}
+	if err = commit(); err != nil {
+		log.Printf("提交事务失败: %v", err)
+	}
+	return err
+}
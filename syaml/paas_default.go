@@ -29,18 +29,64 @@ type GinScanInfo struct {
 }
 
 type GormInfo struct {
-	Driver  string `yaml:"driver"`
-	Url     string `yaml:"url"`
-	MaxOpen int    `yaml:"maxOpen"`
-	MaxIdle int    `yaml:"maxIdle"`
-	ShowSql bool   `yaml:"showSql"`
+	Driver      string       `yaml:"driver"`
+	Url         string       `yaml:"url"`
+	MaxOpen     int          `yaml:"maxOpen"`
+	MaxIdle     int          `yaml:"maxIdle"`
+	ShowSql     bool         `yaml:"showSql"`
+	TablePrefix string       `yaml:"tablePrefix"` //表前缀，实体实现 TableName() 时不添加
+	Replicas    []string     `yaml:"replicas"`    //只读副本连接地址，查询轮询，写操作及事务使用主库
+	Migrate     *MigrateInfo `yaml:"migrate"`     //数据库迁移
+	Tenant      *TenantInfo  `yaml:"tenant"`      //多租户
+	Encrypt     *EncryptInfo `yaml:"encrypt"`     //字段加密
+	//命名数据源，通过 mapper.Use(name) 使用
+	Datasources map[string]*GormInfo `yaml:"datasources"`
 }
 
 type SqlxInfo struct {
-	Driver  string `yaml:"driver"`
-	Url     string `yaml:"url"`
-	MaxOpen int    `yaml:"maxOpen"`
-	MaxIdle int    `yaml:"maxIdle"`
+	Driver       string   `yaml:"driver"`
+	Url          string   `yaml:"url"`
+	MaxOpen      int      `yaml:"maxOpen"`
+	MaxIdle      int      `yaml:"maxIdle"`
+	Timeout      int      `yaml:"timeout"`      //单条SQL执行超时时间（毫秒），0为不限制
+	LogLevel     string   `yaml:"logLevel"`     //SQL日志级别：silent,error,warn,info（默认）
+	SlowSql      int      `yaml:"slowSql"`      //慢SQL阈值（毫秒），0为不记录
+	Redact       []string `yaml:"redact"`       //日志中需要脱敏的参数列名，敏感列名（password、token 等）默认脱敏
+	LogicDeleted string   `yaml:"logicDeleted"` //逻辑删除已删除值，默认1
+	LogicActive  string   `yaml:"logicActive"`  //逻辑删除未删除值，默认0
+	Replicas     []string `yaml:"replicas"`     //只读副本连接地址，读操作轮询，事务固定使用主库
+	TablePrefix  string   `yaml:"tablePrefix"`  //表前缀，实体实现 TableName() 时不添加
+	Templates    string   `yaml:"templates"`    //SQL模板文件匹配模式，如 sql/*.xml
+	//命名数据源，通过 sorm.Use(name) 使用
+	Datasources map[string]*SqlxInfo `yaml:"datasources"`
+	Migrate     *MigrateInfo         `yaml:"migrate"`   //数据库迁移
+	Tenant      *TenantInfo          `yaml:"tenant"`    //多租户
+	DataScope   *DataScopeInfo       `yaml:"dataScope"` //数据权限
+	Encrypt     *EncryptInfo         `yaml:"encrypt"`   //字段加密
+}
+
+type MigrateInfo struct {
+	Dir   string `yaml:"dir"`   //迁移文件目录，使用 smigrate.SetFS 时为其中的目录
+	Table string `yaml:"table"` //已执行版本记录表，默认 schema_migrations
+	Auto  bool   `yaml:"auto"`  //启动时执行待执行的迁移
+}
+
+type TenantInfo struct {
+	Column string   `yaml:"column"` //租户列，默认 tenant_id
+	Key    string   `yaml:"key"`    //请求上下文（JWT）中租户ID的键，默认 tenantId
+	Ignore []string `yaml:"ignore"` //不处理租户的表
+}
+
+type DataScopeInfo struct {
+	UserKey    string `yaml:"userKey"`    //请求上下文（JWT）中用户ID的键，默认 userId
+	DeptKey    string `yaml:"deptKey"`    //部门ID的键，默认 deptId
+	DeptIdsKey string `yaml:"deptIdsKey"` //本部门及下级部门ID的键（数组或逗号分隔），默认 deptIds
+	ScopeKey   string `yaml:"scopeKey"`   //数据范围的键：all、self、dept、deptAndChild、custom，默认 dataScope
+}
+
+type EncryptInfo struct {
+	Key  string            `yaml:"key"`  //加密使用的密钥ID，轮换时修改为新密钥ID，旧密钥保留用于解密
+	Keys map[string]string `yaml:"keys"` //密钥ID -> 密钥（base64编码，AES为16/24/32字节，SM4为16字节）
 }
 
 type SnowflakeInfo struct {